@@ -1,98 +1,397 @@
 package gomodel
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultStmtCacheSize is the default capacity of statement cache of DB
+const DefaultStmtCacheSize = 1024
 
 type (
-	// cacheItem keeps the sql and prepared statement of it
+	// cacheItem keeps the sql and prepared statement of it, the statement is
+	// closed after it's evicted and released by all users
 	cacheItem struct {
+		id   interface{}
 		sql  string
 		stmt *sql.Stmt
+
+		used    int32 // reference bit of CLOCK, set when the item is accessed
+		refs    int32 // count of users hold the statement
+		evicted int32
+		once    sync.Once
+
+		index int // position in the ring of cache, protected by mutex of cache
 	}
 
-	cache map[uint64]cacheItem // map[id]{sql, stmt}
+	// cachedStmt is the statement returned from cache for internal use, it
+	// hold a reference to the cached statement, Close release the reference
+	// instead of closing it
+	cachedStmt struct {
+		*sql.Stmt
+		item   *cacheItem
+		exec   Executor // used to prepare the statement again after evicted
+		closed int32
+	}
+
+	// sharedStmt is the statement returned from cache to users, it doesn't hold
+	// the cached statement, each execution acquire the statement and release
+	// it after executed, so it needn't be closed. If the statement has been
+	// evicted, it's prepared and cached again.
+	sharedStmt struct {
+		cache *cache
+		exec  Executor
+		item  *cacheItem
+	}
+
+	// CacheStats is the statistics of statement cache
+	CacheStats struct {
+		Size      int    // count of cached items
+		Hits      uint64 // lookups found a prepared statement
+		Misses    uint64 // lookups need to prepare statement
+		Evictions uint64 // items evicted for capacity
+	}
+
+	// cache is safe for concurrent use, lookup of cached items is lock-free,
+	// store and eviction of items is serialized by the mutex. If capacity is
+	// positive, items are evicted by the CLOCK algorithm when the count of
+	// items is over capacity: lookup only set the reference bit of item, the
+	// eviction sweep the ring, clear bits of items recently accessed and evict
+	// the first one not accessed since last sweep, it approximates LRU.
+	cache struct {
+		items    sync.Map // map[id]*cacheItem, id is sql id or statement identity
+		capacity int
+
+		mu   sync.Mutex
+		ring []*cacheItem
+		hand int
+
+		hits      uint64
+		misses    uint64
+		evictions uint64
+	}
 )
 
-func newCache() cache {
-	return make(cache)
+// newCache create a cache with the capacity, 0 means unlimited
+func newCache(capacity int) *cache {
+	return &cache{capacity: capacity}
 }
 
-// StmtById search a prepared statement for given sql type by id, if not found,
-// create with the creator, and prepared the sql to a statement, cache it, then
-// return
-func (c cache) StmtById(exec Executor, sqlid uint64) (*sql.Stmt, error) {
-	if item, has := c[sqlid]; has {
-		sqlPrinter.Print(true, item.sql)
+func (i *cacheItem) acquire() bool {
+	atomic.AddInt32(&i.refs, 1)
+	if atomic.LoadInt32(&i.evicted) != 0 {
+		i.release()
+		return false
+	}
+
+	return true
+}
 
-		return item.stmt, nil
+func (i *cacheItem) release() {
+	if atomic.AddInt32(&i.refs, -1) == 0 && atomic.LoadInt32(&i.evicted) != 0 {
+		i.close()
 	}
-	sql_ := SqlById(exec, sqlid)
-	sql_ = exec.Driver().Prepare(sql_)
-	sqlPrinter.Print(false, sql_)
+}
+
+// evict mark the item evicted, the statement is closed immediately if no one
+// hold it, otherwise closed by the last release
+func (i *cacheItem) evict() {
+	atomic.StoreInt32(&i.evicted, 1)
+	if atomic.LoadInt32(&i.refs) == 0 {
+		i.close()
+	}
+}
+
+func (i *cacheItem) close() {
+	i.once.Do(func() {
+		if i.stmt != nil {
+			i.stmt.Close()
+		}
+	})
+}
+
+// touch set the reference bit, it's only written if not set to avoid
+// contention between goroutines accessing the same item
+func (i *cacheItem) touch() {
+	if atomic.LoadInt32(&i.used) == 0 {
+		atomic.StoreInt32(&i.used, 1)
+	}
+}
+
+// ref return a statement hold the reference acquired to item
+func (i *cacheItem) ref(exec Executor) Stmt {
+	return &cachedStmt{Stmt: i.stmt, item: i, exec: exec}
+}
+
+func (s *cachedStmt) Close() error {
+	if atomic.CompareAndSwapInt32(&s.closed, 0, 1) {
+		s.item.release()
+	}
+
+	return nil
+}
+
+// acquire acquire the cached statement, if it has been evicted, prepare and
+// cache it again
+func (s *sharedStmt) acquire(ctx context.Context) (*cacheItem, error) {
+	if s.item.acquire() {
+		return s.item, nil
+	}
+
+	item, _, err := s.cache.acquireItem(ctx, s.exec, s.item.id, func(Driver) string {
+		return s.item.sql
+	})
+	return item, err
+}
+
+func (s *sharedStmt) Exec(args ...interface{}) (sql.Result, error) {
+	return s.ExecContext(context.Background(), args...)
+}
 
-	stmt, err := exec.Prepare(sql_)
+func (s *sharedStmt) Query(args ...interface{}) (*sql.Rows, error) {
+	return s.QueryContext(context.Background(), args...)
+}
+
+func (s *sharedStmt) QueryRow(args ...interface{}) *sql.Row {
+	return s.QueryRowContext(context.Background(), args...)
+}
+
+func (s *sharedStmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	item, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer item.release()
+
+	return item.stmt.ExecContext(ctx, args...)
+}
+
+// QueryContext release the statement after query, if the statement is evicted
+// before rows closed, database/sql close it after rows closed
+func (s *sharedStmt) QueryContext(ctx context.Context, args ...interface{}) (*sql.Rows, error) {
+	item, err := s.acquire(ctx)
 	if err != nil {
 		return nil, err
 	}
-	c[sqlid] = cacheItem{sql: sql_, stmt: stmt}
+	defer item.release()
 
-	return stmt, nil
+	return item.stmt.QueryContext(ctx, args...)
 }
 
-// GetStmt get sql and statement from cacher, if not found, "" and nil was returned
-func (c cache) GetStmt(exec Executor, sqlid uint64) (string, *sql.Stmt, error) {
-	item, has := c[sqlid]
+// QueryRowContext is similar to QueryContext, if the statement can't be
+// prepared again after evicted, the error of row is "statement is closed"
+func (s *sharedStmt) QueryRowContext(ctx context.Context, args ...interface{}) *sql.Row {
+	item, err := s.acquire(ctx)
+	if err != nil {
+		return s.item.stmt.QueryRowContext(ctx, args...)
+	}
+	defer item.release()
+
+	return item.stmt.QueryRowContext(ctx, args...)
+}
+
+// Close do nothing, the statement is closed by cache
+func (s *sharedStmt) Close() error {
+	return nil
+}
+
+// get search the item and set the reference bit
+func (c *cache) get(sqlid interface{}) (*cacheItem, bool) {
+	item, has := c.items.Load(sqlid)
 	if !has {
-		return "", nil, nil
+		return nil, false
 	}
-	var err error
-	if item.stmt == nil {
-		item.sql = exec.Driver().Prepare(item.sql)
-		item.stmt, err = exec.Prepare(item.sql)
+
+	i := item.(*cacheItem)
+	i.touch()
+	return i, true
+}
+
+// acquire search the item has prepared statement and acquire it, the sql is
+// returned if only sql was cached
+func (c *cache) acquire(sqlid interface{}) (string, *cacheItem) {
+	item, has := c.get(sqlid)
+	if !has {
+		atomic.AddUint64(&c.misses, 1)
+		return "", nil
+	}
+	// the item may be evicted by another goroutine after loaded
+	if item.stmt == nil || !item.acquire() {
+		atomic.AddUint64(&c.misses, 1)
+		return item.sql, nil
 	}
 
-	return item.sql, item.stmt, err
+	atomic.AddUint64(&c.hits, 1)
+	return item.sql, item
 }
 
-// SetStmt exec a sql to statement, cache then return it
-func (c cache) SetStmt(exec Executor, sqlid uint64, sql string) (*sql.Stmt, error) {
-	sql = exec.Driver().Prepare(sql)
-	stmt, err := exec.Prepare(sql)
+// set store the item and return the final cached item acquired, if another
+// goroutine has already cached a statement for the id, the statement of item
+// will be closed and the exist one is returned
+func (c *cache) set(sqlid interface{}, sql string, stmt *sql.Stmt) *cacheItem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// items will not be evicted while holding the mutex, so acquire always
+	// succeed
+	exist, has := c.get(sqlid)
+	if has && stmt == nil {
+		return exist
+	}
+	if has && exist.stmt != nil {
+		exist.acquire()
+		stmt.Close()
+		return exist
+	}
+
+	// the reference bit is set to avoid evicting the new item immediately
+	item := &cacheItem{id: sqlid, sql: sql, stmt: stmt, used: 1}
+	if stmt != nil {
+		item.refs = 1
+	}
+	c.items.Store(sqlid, item)
+	if has {
+		item.index = exist.index
+		c.ring[item.index] = item
+	} else {
+		item.index = len(c.ring)
+		c.ring = append(c.ring, item)
+		c.evict()
+	}
+
+	return item
+}
+
+// evict remove items until the count of items is not over capacity, the
+// mutex must be held
+func (c *cache) evict() {
+	for c.capacity > 0 && len(c.ring) > c.capacity {
+		if c.hand >= len(c.ring) {
+			c.hand = 0
+		}
+
+		item := c.ring[c.hand]
+		if atomic.LoadInt32(&item.used) != 0 {
+			atomic.StoreInt32(&item.used, 0)
+			c.hand++
+			continue
+		}
+
+		// move the last item to the position, it will be checked next
+		last := len(c.ring) - 1
+		c.ring[c.hand] = c.ring[last]
+		c.ring[c.hand].index = c.hand
+		c.ring[last] = nil
+		c.ring = c.ring[:last]
+
+		c.items.Delete(item.id)
+		atomic.AddUint64(&c.evictions, 1)
+		item.evict()
+	}
+}
+
+// acquireItem acquire the item has prepared statement, if not found, prepare
+// the sql returned by build and cache the statement, the sql is built only if
+// it's not cached. The bool result is whether the statement is cached.
+func (c *cache) acquireItem(ctx context.Context, exec Executor, sqlid interface{}, build func(Driver) string) (*cacheItem, bool, error) {
+	sql_, item := c.acquire(sqlid)
+	if item != nil {
+		sqlPrinter.Print(true, sql_)
+		return item, true, nil
+	}
+
+	if sql_ == "" {
+		sql_ = build(exec.Driver())
+	}
+	sqlPrinter.Print(false, sql_)
+
+	stmt, err := exec.PrepareContext(ctx, sql_)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	c[sqlid] = cacheItem{
-		sql:  sql,
-		stmt: stmt,
+
+	return c.set(sqlid, sql_, stmt), false, nil
+}
+
+// AcquireStmt search a prepared statement by id, if not found, build the sql
+// and prepare it to a statement, cache it, then return. The returned statement
+// hold a reference to cached statement, it must be closed after used to
+// release the reference.
+func (c *cache) AcquireStmt(ctx context.Context, exec Executor, info stmtInfo, sqlid interface{}, build func(Driver) string) (Stmt, error) {
+	item, cached, err := c.acquireItem(ctx, exec, sqlid, func(dri Driver) string {
+		return dri.Prepare(build(dri))
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return stmt, nil
+	info.sql, info.cached = item.sql, cached
+	return withHook(exec, item.ref(exec), info), nil
 }
 
-func (c cache) PrepareById(exec Executor, sqlid uint64) (*sql.Stmt, error) {
-	item, has := c[sqlid]
-	if !has {
-		item.sql = exec.Driver().Prepare(SqlById(exec, sqlid))
-		c[sqlid] = item
+// Share convert the statement returned by AcquireStmt to the one doesn't hold
+// the cached statement, the reference is released.
+func (c *cache) Share(stmt Stmt, err error) (Stmt, error) {
+	if err != nil {
+		return nil, err
 	}
-	sqlPrinter.Print(has, item.sql)
 
-	stmt, err := exec.Prepare(item.sql)
-	return stmt, err
+	s, info := unwrapHook(stmt)
+	cs := s.(*cachedStmt)
+	cs.Close()
+	return withHook(cs.exec, &sharedStmt{cache: c, exec: cs.exec, item: cs.item}, info), nil
 }
 
-func (c cache) PrepareSQL(exec Executor, sqlid uint64) (string, *sql.Stmt, error) {
-	item, has := c[sqlid]
+// AcquireStmtById search a prepared statement for given sql type by id, if not
+// found, create with the creator, and prepared the sql to a statement, cache
+// it, then return. The returned statement must be closed, see AcquireStmt.
+func (c *cache) AcquireStmtById(ctx context.Context, exec Executor, sqlid uint64) (Stmt, error) {
+	return c.AcquireStmt(ctx, exec, stmtInfo{sqlType: RAW, sqlid: sqlid, byId: true}, sqlid, func(Driver) string {
+		return SqlById(exec, sqlid)
+	})
+}
+
+// PrepareSQL prepare the cached sql to a new statement, if not found, "" and
+// nil was returned
+func (c *cache) PrepareSQL(ctx context.Context, exec Executor, sqlid interface{}) (string, *sql.Stmt, error) {
+	item, has := c.get(sqlid)
 	if !has {
 		return "", nil, nil
 	}
 
-	item.sql = exec.Driver().Prepare(item.sql)
-	stmt, err := exec.Prepare(item.sql)
+	stmt, err := exec.PrepareContext(ctx, item.sql)
 	return item.sql, stmt, err
 }
 
-func (c cache) SetSQL(sqlid uint64, sql string) {
-	c[sqlid] = cacheItem{
-		sql: sql,
+func (c *cache) SetSQL(sqlid interface{}, sql string) {
+	c.set(sqlid, sql, nil)
+}
+
+// Stats return the statistics of cache
+func (c *cache) Stats() CacheStats {
+	c.mu.Lock()
+	size := len(c.ring)
+	c.mu.Unlock()
+
+	return CacheStats{
+		Size:      size,
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+	}
+}
+
+// Close remove all items, statements are closed after released by all users
+func (c *cache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, item := range c.ring {
+		c.items.Delete(item.id)
+		item.evict()
 	}
+	c.ring = nil
+	c.hand = 0
 }
@@ -69,7 +69,8 @@ func main() {
 		data.SQLs = v.SQLs
 	}
 	if flags.Model {
-		data.Models = v.buildModelFields()
+		data.Models, err = v.buildModelFields()
+		utils.FatalOnError(err)
 	}
 
 	utils.FatalOnError(executeTemplate(flags.Out, flags.Pkg, tmpl, data))
@@ -33,12 +33,18 @@ import (
 {{$self := $model.Self}}
 {{$recv := (printf "(%s *%s)" $self $normal)}}
 const (
+{{if $model.Wide}}
+    {{range $index, $field := $fields}}{{$upper}}_{{$field.Upper}} {{if eq $index  0}} = iota {{end}}
+    {{end}}
+    {{$normal}}FieldEnd = iota
+{{else}}
     {{range $index, $field := $fields}}{{$upper}}_{{$field.Upper}} {{if eq $index  0}} uint64 = 1 << iota {{end}}
     {{end}}
     {{$normal}}FieldEnd = iota
     {{$normal}}FieldsAll = 1 << {{$normal}}FieldEnd-1
     {{range $index, $field := $fields}}{{$normal}}FieldsExcp{{$field.Name}} = {{$normal}}FieldsAll &(^{{$upper}}_{{$field.Upper}})
     {{end}}
+{{end}}
 
     {{$normal}}Table = "{{$model.Table}}"
     {{range $index, $field := $fields}}{{$normal}}{{$field.Name}}Col = "{{$field.Column}}"
@@ -47,6 +53,7 @@ const (
 
 var (
     {{$normal}}Instance = new({{$normal}})
+    {{if $model.Wide}}{{$normal}}FieldsAll = gomodel.FieldsetAll({{$normal}}FieldEnd){{end}}
 )
 
 func {{$recv}} Table() string {
@@ -59,12 +66,61 @@ func {{$recv}} Nocache() bool {
 }
 {{end}}
 
+{{if $model.Version}}
+func {{$recv}} VersionField() uint64 {
+    return {{$upper}}_{{$model.Version}}
+}
+{{end}}
+
+{{if $model.SoftDelete}}
+func {{$recv}} SoftDeleteField() uint64 {
+    return {{$upper}}_{{$model.SoftDelete}}
+}
+{{end}}
+
+{{if or $model.AutoCreate $model.AutoUpdate}}
+func {{$recv}} AutoCreateFields() uint64 {
+    return {{range $index, $field := $model.AutoCreate}}{{if $index}}|{{end}}{{$upper}}_{{$field}}{{else}}0{{end}}
+}
+
+func {{$recv}} AutoUpdateFields() uint64 {
+    return {{range $index, $field := $model.AutoUpdate}}{{if $index}}|{{end}}{{$upper}}_{{$field}}{{else}}0{{end}}
+}
+{{end}}
+
 func {{$recv}} Columns() []string {
     return []string{
     {{range $index, $field:=$fields}}{{$normal}}{{$field.Name}}Col,{{end}}
     }
 }
 
+{{if $model.Wide}}
+func {{$recv}} Vals(fields uint64, vals []interface{}) {
+    {{$self}}.WideVals(gomodel.Fieldset{fields}, vals)
+}
+
+func {{$recv}} WideVals(fields gomodel.Fieldset, vals []interface{}) {
+    index := 0
+    {{range $fields}} if fields.Has({{$upper}}_{{.Upper}}) {
+        vals[index] = {{$self}}.{{.Name}}
+        index++
+    }
+    {{end}}
+}
+
+func {{$recv}} Ptrs(fields uint64, ptrs []interface{}) {
+    {{$self}}.WidePtrs(gomodel.Fieldset{fields}, ptrs)
+}
+
+func {{$recv}} WidePtrs(fields gomodel.Fieldset, ptrs []interface{}) {
+    index := 0
+    {{range $fields}} if fields.Has({{$upper}}_{{.Upper}}) {
+        ptrs[index] = &({{$self}}.{{.Name}})
+        index++
+    }
+    {{end}}
+}
+{{else}}
 func {{$recv}} Vals(fields uint64, vals []interface{}) {
     if fields != 0 {
     if fields == {{$normal}}FieldsAll {
@@ -94,34 +150,42 @@ func {{$recv}} Ptrs(fields uint64, ptrs []interface{}) {
     {{end}}}
     }
 }
+{{end}}
 
 func {{$recv}} TxDo(exec gomodel.Executor, do func(*gomodel.Tx, *{{$normal}}) error) error {
-    var (
-        tx *gomodel.Tx
-        err error
-    )
+    return {{$self}}.TxDoWith(exec, gomodel.TxOptions{}, do)
+}
+
+func {{$recv}} TxDoWith(exec gomodel.Executor, opts gomodel.TxOptions, do func(*gomodel.Tx, *{{$normal}}) error) error {
     switch r := exec.(type) {
     case *gomodel.Tx:
-        tx = r
+        if !r.Compatible(opts) {
+            return gomodel.ErrTxIncompatible
+        }
+        return r.TxDo(func(tx *gomodel.Tx) error {
+            return do(tx, {{$self}})
+        })
     case *gomodel.DB:
-        tx, err = r.Begin()
-    	if err != nil {
-    		return err
-    	}
-    	defer tx.Close()
+        return r.TxDoWith(opts, func(tx *gomodel.Tx) error {
+            return do(tx, {{$self}})
+        })
+    case *gomodel.ShardTx:
+        tx, _ := r.Tx()
+        if tx == nil {
+            return gomodel.ErrNoShard
+        }
+        return {{$self}}.TxDoWith(tx, opts, do)
+    case *gomodel.Shards:
+        return gomodel.ErrNoShard
     default:
         panic("unexpected underlay type of gomodel.Executor")
     }
-
-    err = do(tx, {{$self}})
-    tx.Success(err == nil)
-    return err
 }
 
 type (
     {{$normal}}Store struct {
         Values []{{$normal}}
-        Fields uint64
+        Fields {{if $model.Wide}}gomodel.Fieldset{{else}}uint64{{end}}
     }
 )
 
@@ -138,7 +202,11 @@ func (s *{{$normal}}Store) Final(size int) {
 }
 
 func (s *{{$normal}}Store) Ptrs(index int, ptrs []interface{}) {
-    s.Values[index].Ptrs(s.Fields, ptrs)
+    s.Values[index].{{if $model.Wide}}WidePtrs{{else}}Ptrs{{end}}(s.Fields, ptrs)
+}
+
+func (s *{{$normal}}Store) Model(index int) gomodel.Model {
+    return &s.Values[index]
 }
 
 func (s *{{$normal}}Store) Realloc(count int) int {
@@ -1,5 +1,7 @@
 package test
 
+import "time"
+
 //go:generate gomodel $GOFILE
 
 type User struct {
@@ -15,3 +17,12 @@ type Follow struct {
 	UserId       int64 `table:"user_follow"`
 	FollowUserId int64
 }
+
+type Article struct {
+	Id        int64
+	Title     string
+	Version   int        `version:"true"`
+	CreatedAt time.Time  `autocreate:"true"`
+	UpdatedAt *time.Time `autoupdate:"true"`
+	DeletedAt *time.Time `softdelete:"true"`
+}
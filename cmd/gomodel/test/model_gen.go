@@ -31,107 +31,114 @@ var (
 	UserInstance = new(User)
 )
 
-func (uu *User) Table() string {
+func (u *User) Table() string {
 	return UserTable
 }
 
-func (uu *User) Columns() []string {
+func (u *User) Columns() []string {
 	return []string{
 		UserIdCol, UserNameCol, UserAgeCol, UserFollowingsCol, UserFollowersCol,
 	}
 }
 
-func (uu *User) Vals(fields uint64, vals []interface{}) {
+func (u *User) Vals(fields uint64, vals []interface{}) {
 	if fields != 0 {
 		if fields == UserFieldsAll {
-			vals[0] = uu.Id
-			vals[1] = uu.Name
-			vals[2] = uu.Age
-			vals[3] = uu.Followings
-			vals[4] = uu.Followers
+			vals[0] = u.Id
+			vals[1] = u.Name
+			vals[2] = u.Age
+			vals[3] = u.Followings
+			vals[4] = u.Followers
 
 		} else {
 			index := 0
 			if fields&USER_ID != 0 {
-				vals[index] = uu.Id
+				vals[index] = u.Id
 				index++
 			}
 			if fields&USER_NAME != 0 {
-				vals[index] = uu.Name
+				vals[index] = u.Name
 				index++
 			}
 			if fields&USER_AGE != 0 {
-				vals[index] = uu.Age
+				vals[index] = u.Age
 				index++
 			}
 			if fields&USER_FOLLOWINGS != 0 {
-				vals[index] = uu.Followings
+				vals[index] = u.Followings
 				index++
 			}
 			if fields&USER_FOLLOWERS != 0 {
-				vals[index] = uu.Followers
+				vals[index] = u.Followers
 				index++
 			}
 		}
 	}
 }
 
-func (uu *User) Ptrs(fields uint64, ptrs []interface{}) {
+func (u *User) Ptrs(fields uint64, ptrs []interface{}) {
 	if fields != 0 {
 		if fields == UserFieldsAll {
-			ptrs[0] = &(uu.Id)
-			ptrs[1] = &(uu.Name)
-			ptrs[2] = &(uu.Age)
-			ptrs[3] = &(uu.Followings)
-			ptrs[4] = &(uu.Followers)
+			ptrs[0] = &(u.Id)
+			ptrs[1] = &(u.Name)
+			ptrs[2] = &(u.Age)
+			ptrs[3] = &(u.Followings)
+			ptrs[4] = &(u.Followers)
 
 		} else {
 			index := 0
 			if fields&USER_ID != 0 {
-				ptrs[index] = &(uu.Id)
+				ptrs[index] = &(u.Id)
 				index++
 			}
 			if fields&USER_NAME != 0 {
-				ptrs[index] = &(uu.Name)
+				ptrs[index] = &(u.Name)
 				index++
 			}
 			if fields&USER_AGE != 0 {
-				ptrs[index] = &(uu.Age)
+				ptrs[index] = &(u.Age)
 				index++
 			}
 			if fields&USER_FOLLOWINGS != 0 {
-				ptrs[index] = &(uu.Followings)
+				ptrs[index] = &(u.Followings)
 				index++
 			}
 			if fields&USER_FOLLOWERS != 0 {
-				ptrs[index] = &(uu.Followers)
+				ptrs[index] = &(u.Followers)
 				index++
 			}
 		}
 	}
 }
 
-func (uu *User) TxDo(exec gomodel.Executor, do func(*gomodel.Tx, *User) error) error {
-	var (
-		tx  *gomodel.Tx
-		err error
-	)
+func (u *User) TxDo(exec gomodel.Executor, do func(*gomodel.Tx, *User) error) error {
+	return u.TxDoWith(exec, gomodel.TxOptions{}, do)
+}
+
+func (u *User) TxDoWith(exec gomodel.Executor, opts gomodel.TxOptions, do func(*gomodel.Tx, *User) error) error {
 	switch r := exec.(type) {
 	case *gomodel.Tx:
-		tx = r
+		if !r.Compatible(opts) {
+			return gomodel.ErrTxIncompatible
+		}
+		return r.TxDo(func(tx *gomodel.Tx) error {
+			return do(tx, u)
+		})
 	case *gomodel.DB:
-		tx, err = r.Begin()
-		if err != nil {
-			return err
+		return r.TxDoWith(opts, func(tx *gomodel.Tx) error {
+			return do(tx, u)
+		})
+	case *gomodel.ShardTx:
+		tx, _ := r.Tx()
+		if tx == nil {
+			return gomodel.ErrNoShard
 		}
-		defer tx.Close()
+		return u.TxDoWith(tx, opts, do)
+	case *gomodel.Shards:
+		return gomodel.ErrNoShard
 	default:
 		panic("unexpected underlay type of gomodel.Executor")
 	}
-
-	err = do(tx, uu)
-	tx.Success(err == nil)
-	return err
 }
 
 type (
@@ -157,6 +164,10 @@ func (s *UserStore) Ptrs(index int, ptrs []interface{}) {
 	s.Values[index].Ptrs(s.Fields, ptrs)
 }
 
+func (s *UserStore) Model(index int) gomodel.Model {
+	return &s.Values[index]
+}
+
 func (s *UserStore) Realloc(count int) int {
 	if c := cap(s.Values); c == count {
 		values := make([]User, 2*c)
@@ -197,77 +208,84 @@ var (
 	FollowInstance = new(Follow)
 )
 
-func (ff *Follow) Table() string {
+func (f *Follow) Table() string {
 	return FollowTable
 }
 
-func (ff *Follow) Columns() []string {
+func (f *Follow) Columns() []string {
 	return []string{
 		FollowUserIdCol, FollowFollowUserIdCol,
 	}
 }
 
-func (ff *Follow) Vals(fields uint64, vals []interface{}) {
+func (f *Follow) Vals(fields uint64, vals []interface{}) {
 	if fields != 0 {
 		if fields == FollowFieldsAll {
-			vals[0] = ff.UserId
-			vals[1] = ff.FollowUserId
+			vals[0] = f.UserId
+			vals[1] = f.FollowUserId
 
 		} else {
 			index := 0
 			if fields&FOLLOW_USERID != 0 {
-				vals[index] = ff.UserId
+				vals[index] = f.UserId
 				index++
 			}
 			if fields&FOLLOW_FOLLOWUSERID != 0 {
-				vals[index] = ff.FollowUserId
+				vals[index] = f.FollowUserId
 				index++
 			}
 		}
 	}
 }
 
-func (ff *Follow) Ptrs(fields uint64, ptrs []interface{}) {
+func (f *Follow) Ptrs(fields uint64, ptrs []interface{}) {
 	if fields != 0 {
 		if fields == FollowFieldsAll {
-			ptrs[0] = &(ff.UserId)
-			ptrs[1] = &(ff.FollowUserId)
+			ptrs[0] = &(f.UserId)
+			ptrs[1] = &(f.FollowUserId)
 
 		} else {
 			index := 0
 			if fields&FOLLOW_USERID != 0 {
-				ptrs[index] = &(ff.UserId)
+				ptrs[index] = &(f.UserId)
 				index++
 			}
 			if fields&FOLLOW_FOLLOWUSERID != 0 {
-				ptrs[index] = &(ff.FollowUserId)
+				ptrs[index] = &(f.FollowUserId)
 				index++
 			}
 		}
 	}
 }
 
-func (ff *Follow) TxDo(exec gomodel.Executor, do func(*gomodel.Tx, *Follow) error) error {
-	var (
-		tx  *gomodel.Tx
-		err error
-	)
+func (f *Follow) TxDo(exec gomodel.Executor, do func(*gomodel.Tx, *Follow) error) error {
+	return f.TxDoWith(exec, gomodel.TxOptions{}, do)
+}
+
+func (f *Follow) TxDoWith(exec gomodel.Executor, opts gomodel.TxOptions, do func(*gomodel.Tx, *Follow) error) error {
 	switch r := exec.(type) {
 	case *gomodel.Tx:
-		tx = r
+		if !r.Compatible(opts) {
+			return gomodel.ErrTxIncompatible
+		}
+		return r.TxDo(func(tx *gomodel.Tx) error {
+			return do(tx, f)
+		})
 	case *gomodel.DB:
-		tx, err = r.Begin()
-		if err != nil {
-			return err
+		return r.TxDoWith(opts, func(tx *gomodel.Tx) error {
+			return do(tx, f)
+		})
+	case *gomodel.ShardTx:
+		tx, _ := r.Tx()
+		if tx == nil {
+			return gomodel.ErrNoShard
 		}
-		defer tx.Close()
+		return f.TxDoWith(tx, opts, do)
+	case *gomodel.Shards:
+		return gomodel.ErrNoShard
 	default:
 		panic("unexpected underlay type of gomodel.Executor")
 	}
-
-	err = do(tx, ff)
-	tx.Success(err == nil)
-	return err
 }
 
 type (
@@ -293,6 +311,10 @@ func (s *FollowStore) Ptrs(index int, ptrs []interface{}) {
 	s.Values[index].Ptrs(s.Fields, ptrs)
 }
 
+func (s *FollowStore) Model(index int) gomodel.Model {
+	return &s.Values[index]
+}
+
 func (s *FollowStore) Realloc(count int) int {
 	if c := cap(s.Values); c == count {
 		values := make([]Follow, 2*c)
@@ -315,9 +337,217 @@ func (a *FollowStore) Clear() {
 	}
 }
 
-// Generated SQL
+const (
+	ARTICLE_ID uint64 = 1 << iota
+	ARTICLE_TITLE
+	ARTICLE_VERSION
+	ARTICLE_CREATEDAT
+	ARTICLE_UPDATEDAT
+	ARTICLE_DELETEDAT
+
+	ArticleFieldEnd            = iota
+	ArticleFieldsAll           = 1<<ArticleFieldEnd - 1
+	ArticleFieldsExcpId        = ArticleFieldsAll & (^ARTICLE_ID)
+	ArticleFieldsExcpTitle     = ArticleFieldsAll & (^ARTICLE_TITLE)
+	ArticleFieldsExcpVersion   = ArticleFieldsAll & (^ARTICLE_VERSION)
+	ArticleFieldsExcpCreatedAt = ArticleFieldsAll & (^ARTICLE_CREATEDAT)
+	ArticleFieldsExcpUpdatedAt = ArticleFieldsAll & (^ARTICLE_UPDATEDAT)
+	ArticleFieldsExcpDeletedAt = ArticleFieldsAll & (^ARTICLE_DELETEDAT)
+
+	ArticleTable        = "article"
+	ArticleIdCol        = "id"
+	ArticleTitleCol     = "title"
+	ArticleVersionCol   = "version"
+	ArticleCreatedAtCol = "created_at"
+	ArticleUpdatedAtCol = "updated_at"
+	ArticleDeletedAtCol = "deleted_at"
+)
+
 var (
-	astConv = gomodel.NewSqlId(func(gomodel.Executor) string {
-		return "insert into user_follow(user_id, follow_user_id) select ?, ? from DUAL where exists (select id from user where id = ?)"
-	})
+	ArticleInstance = new(Article)
+)
+
+func (a *Article) Table() string {
+	return ArticleTable
+}
+
+func (a *Article) VersionField() uint64 {
+	return ARTICLE_VERSION
+}
+
+func (a *Article) SoftDeleteField() uint64 {
+	return ARTICLE_DELETEDAT
+}
+
+func (a *Article) AutoCreateFields() uint64 {
+	return ARTICLE_CREATEDAT
+}
+
+func (a *Article) AutoUpdateFields() uint64 {
+	return ARTICLE_UPDATEDAT
+}
+
+func (a *Article) Columns() []string {
+	return []string{
+		ArticleIdCol, ArticleTitleCol, ArticleVersionCol, ArticleCreatedAtCol, ArticleUpdatedAtCol, ArticleDeletedAtCol,
+	}
+}
+
+func (a *Article) Vals(fields uint64, vals []interface{}) {
+	if fields != 0 {
+		if fields == ArticleFieldsAll {
+			vals[0] = a.Id
+			vals[1] = a.Title
+			vals[2] = a.Version
+			vals[3] = a.CreatedAt
+			vals[4] = a.UpdatedAt
+			vals[5] = a.DeletedAt
+
+		} else {
+			index := 0
+			if fields&ARTICLE_ID != 0 {
+				vals[index] = a.Id
+				index++
+			}
+			if fields&ARTICLE_TITLE != 0 {
+				vals[index] = a.Title
+				index++
+			}
+			if fields&ARTICLE_VERSION != 0 {
+				vals[index] = a.Version
+				index++
+			}
+			if fields&ARTICLE_CREATEDAT != 0 {
+				vals[index] = a.CreatedAt
+				index++
+			}
+			if fields&ARTICLE_UPDATEDAT != 0 {
+				vals[index] = a.UpdatedAt
+				index++
+			}
+			if fields&ARTICLE_DELETEDAT != 0 {
+				vals[index] = a.DeletedAt
+				index++
+			}
+		}
+	}
+}
+
+func (a *Article) Ptrs(fields uint64, ptrs []interface{}) {
+	if fields != 0 {
+		if fields == ArticleFieldsAll {
+			ptrs[0] = &(a.Id)
+			ptrs[1] = &(a.Title)
+			ptrs[2] = &(a.Version)
+			ptrs[3] = &(a.CreatedAt)
+			ptrs[4] = &(a.UpdatedAt)
+			ptrs[5] = &(a.DeletedAt)
+
+		} else {
+			index := 0
+			if fields&ARTICLE_ID != 0 {
+				ptrs[index] = &(a.Id)
+				index++
+			}
+			if fields&ARTICLE_TITLE != 0 {
+				ptrs[index] = &(a.Title)
+				index++
+			}
+			if fields&ARTICLE_VERSION != 0 {
+				ptrs[index] = &(a.Version)
+				index++
+			}
+			if fields&ARTICLE_CREATEDAT != 0 {
+				ptrs[index] = &(a.CreatedAt)
+				index++
+			}
+			if fields&ARTICLE_UPDATEDAT != 0 {
+				ptrs[index] = &(a.UpdatedAt)
+				index++
+			}
+			if fields&ARTICLE_DELETEDAT != 0 {
+				ptrs[index] = &(a.DeletedAt)
+				index++
+			}
+		}
+	}
+}
+
+func (a *Article) TxDo(exec gomodel.Executor, do func(*gomodel.Tx, *Article) error) error {
+	return a.TxDoWith(exec, gomodel.TxOptions{}, do)
+}
+
+func (a *Article) TxDoWith(exec gomodel.Executor, opts gomodel.TxOptions, do func(*gomodel.Tx, *Article) error) error {
+	switch r := exec.(type) {
+	case *gomodel.Tx:
+		if !r.Compatible(opts) {
+			return gomodel.ErrTxIncompatible
+		}
+		return r.TxDo(func(tx *gomodel.Tx) error {
+			return do(tx, a)
+		})
+	case *gomodel.DB:
+		return r.TxDoWith(opts, func(tx *gomodel.Tx) error {
+			return do(tx, a)
+		})
+	case *gomodel.ShardTx:
+		tx, _ := r.Tx()
+		if tx == nil {
+			return gomodel.ErrNoShard
+		}
+		return a.TxDoWith(tx, opts, do)
+	case *gomodel.Shards:
+		return gomodel.ErrNoShard
+	default:
+		panic("unexpected underlay type of gomodel.Executor")
+	}
+}
+
+type (
+	ArticleStore struct {
+		Values []Article
+		Fields uint64
+	}
 )
+
+func (s *ArticleStore) Init(size int) {
+	if cap(s.Values) < size {
+		s.Values = make([]Article, size)
+	} else {
+		s.Values = s.Values[:size]
+	}
+}
+
+func (s *ArticleStore) Final(size int) {
+	s.Values = s.Values[:size]
+}
+
+func (s *ArticleStore) Ptrs(index int, ptrs []interface{}) {
+	s.Values[index].Ptrs(s.Fields, ptrs)
+}
+
+func (s *ArticleStore) Model(index int) gomodel.Model {
+	return &s.Values[index]
+}
+
+func (s *ArticleStore) Realloc(count int) int {
+	if c := cap(s.Values); c == count {
+		values := make([]Article, 2*c)
+		copy(values, s.Values)
+		s.Values = values
+
+		return 2 * c
+	} else if c > count {
+		s.Values = s.Values[:c]
+
+		return c
+	}
+
+	panic("unexpected capacity of ArticleStore")
+}
+
+func (a *ArticleStore) Clear() {
+	if a.Values != nil {
+		a.Values = a.Values[:0]
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/cosiner/gohper/ds/sortedmap"
@@ -17,10 +19,15 @@ type Model struct {
 	Upper      string
 	Table      string
 	Nocache    string
+	Version    string   // const name of version field
+	SoftDelete string   // const name of soft delete field
+	AutoCreate []string // const names of auto create fields
+	AutoUpdate []string // const names of auto update fields
+	Wide       bool     // fields count is over 64, use gomodel.Fieldset
 }
 
-func NewModel(name, table, nocache string) *Model {
-	return &Model{
+func NewModel(name, table, nocache, version, softDelete string, autoCreate, autoUpdate []string) *Model {
+	m := &Model{
 		Name:       name,
 		Self:       strings.ToLower(name[:1]),
 		Unexported: utils.UnexportedName(name),
@@ -28,6 +35,20 @@ func NewModel(name, table, nocache string) *Model {
 		Table:      table,
 		Nocache:    nocache,
 	}
+	if version != "" {
+		m.Version = strings.ToUpper(version)
+	}
+	if softDelete != "" {
+		m.SoftDelete = strings.ToUpper(softDelete)
+	}
+	for _, field := range autoCreate {
+		m.AutoCreate = append(m.AutoCreate, strings.ToUpper(field))
+	}
+	for _, field := range autoUpdate {
+		m.AutoUpdate = append(m.AutoUpdate, strings.ToUpper(field))
+	}
+
+	return m
 }
 
 type Field struct {
@@ -45,9 +66,13 @@ func NewField(field, col string) *Field {
 }
 
 type Table struct {
-	Name    string
-	Nocache string
-	Fields  sortedmap.Map
+	Name       string
+	Nocache    string
+	Version    string   // version field for optimistic locking
+	SoftDelete string   // soft delete field
+	AutoCreate []string // fields set to current time by insert
+	AutoUpdate []string // fields set to current time by insert and update
+	Fields     sortedmap.Map
 
 	initialed bool
 }
@@ -96,6 +121,40 @@ func (v Visitor) addNocahe(model, nocache string) {
 	t.Nocache = nocache
 }
 
+func (v Visitor) addVersion(model, field string) {
+	t, has := v.Models[model]
+	if !has {
+		t = &Table{}
+		v.Models[model] = t
+	}
+
+	t.Version = field
+}
+
+func (v Visitor) addSoftDelete(model, field string) {
+	t, has := v.Models[model]
+	if !has {
+		t = &Table{}
+		v.Models[model] = t
+	}
+
+	t.SoftDelete = field
+}
+
+func (v Visitor) addAutoTime(model, field string, create bool) {
+	t, has := v.Models[model]
+	if !has {
+		t = &Table{}
+		v.Models[model] = t
+	}
+
+	if create {
+		t.AutoCreate = append(t.AutoCreate, field)
+	} else {
+		t.AutoUpdate = append(t.AutoUpdate, field)
+	}
+}
+
 func (v Visitor) parseFiles(files ...string) error {
 	for _, file := range files {
 		err := v.parseFile(file)
@@ -136,6 +195,19 @@ func (v Visitor) parseFile(file string) error {
 
 				if col := a.S.Tag.Get("column"); col != "-" {
 					v.add(a.TypeName, table, a.S.Field, col)
+
+					if version, _ := strconv.ParseBool(a.S.Tag.Get("version")); version {
+						v.addVersion(a.TypeName, a.S.Field)
+					}
+					if softDelete, _ := strconv.ParseBool(a.S.Tag.Get("softdelete")); softDelete {
+						v.addSoftDelete(a.TypeName, a.S.Field)
+					}
+					if create, _ := strconv.ParseBool(a.S.Tag.Get("autocreate")); create {
+						v.addAutoTime(a.TypeName, a.S.Field, true)
+					}
+					if update, _ := strconv.ParseBool(a.S.Tag.Get("autoupdate")); update {
+						v.addAutoTime(a.TypeName, a.S.Field, false)
+					}
 				}
 			}
 			return nil
@@ -151,20 +223,26 @@ func (v Visitor) parseFile(file string) error {
 	return parser.ParseFile(file)
 }
 
-// buildModelFields build model map from parse result
-func (v Visitor) buildModelFields() map[*Model][]*Field {
+// buildModelFields build model map from parse result, version, softdelete,
+// autocreate and autoupdate tags are rejected for models have more than 64
+// fields
+func (v Visitor) buildModelFields() (map[*Model][]*Field, error) {
 	names := make(map[*Model][]*Field, len(v.Models))
 
 	for model, table := range v.Models {
-		m := NewModel(model, table.Name, table.Nocache)
+		m := NewModel(model, table.Name, table.Nocache, table.Version, table.SoftDelete, table.AutoCreate, table.AutoUpdate)
 		fields := table.Fields
 
 		for _, field := range fields.Values {
 			names[m] = append(names[m], NewField(field.Key, field.Value.(string)))
 		}
+		m.Wide = len(fields.Values) > 64
+		if m.Wide && (m.Version != "" || m.SoftDelete != "" || len(m.AutoCreate) != 0 || len(m.AutoUpdate) != 0) {
+			return nil, fmt.Errorf("%s: version, softdelete, autocreate and autoupdate tags are not supported for models have more than 64 fields", model)
+		}
 	}
 
-	return names
+	return names, nil
 }
 
 func (v Visitor) extractSQLs(docs []string) {
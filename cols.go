@@ -53,6 +53,16 @@ type (
 
 const _emptyCols = emptyCols("")
 
+// newMultipleCols create MultipleCols with all strings prebuilt, it's safe for
+// concurrent use
+func newMultipleCols(names []string) *MultipleCols {
+	c := &MultipleCols{Cols: names}
+	c.str, c.paramed = c.String(), c.Paramed()
+	c.incrParamed, c.onlyParamed = c.IncrParamed(), c.OnlyParam()
+
+	return c
+}
+
 func (c *MultipleCols) Names() []string {
 	return c.Cols
 }
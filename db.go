@@ -2,39 +2,73 @@
 package gomodel
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/cosiner/gomodel/utils"
 )
 
 type (
-	// DB holds database connections, store all tables
+	// DB holds database connections, store all tables, it's safe for
+	// concurrent use
 	DB struct {
 		*sql.DB
-		driver Driver
-		tables map[string]*Table
-		cache  cache
+		driver   Driver
+		tables   sync.Map // map[string]*Table
+		cache    *cache
+		replicas replicaSet
 
 		// initial models count for select 'All', default 20
 		InitialModels int
+		// Hook is called around every execution of statements, it should be
+		// set before use
+		Hook QueryHook
+		// Metrics record every execution of statements, it should be set
+		// before use
+		Metrics Metrics
+		// Tracer start span for every execution of statements and
+		// transactions, it should be set before use
+		Tracer Tracer
+		// Clock return current time for auto timestamp and soft delete fields,
+		// default time.Now, it's mainly used for tests
+		Clock func() time.Time
 	}
+
+	// DBOption configure the DB when it's created
+	DBOption func(*DB)
 )
 
+// WithStmtCacheSize set the capacity of statement cache shared by all tables,
+// the least recently used statements are closed when it's exceeded, 0 means
+// unlimited, default DefaultStmtCacheSize
+func WithStmtCacheSize(size int) DBOption {
+	return func(db *DB) {
+		db.cache = newCache(size)
+	}
+}
+
 // Open create a database manager and connect to database server
-func Open(driver Driver, dsn string, maxIdle, maxOpen int) (*DB, error) {
-	db := NewDB()
+func Open(driver Driver, dsn string, maxIdle, maxOpen int, opts ...DBOption) (*DB, error) {
+	db := NewDB(opts...)
 	err := db.Connect(driver, dsn, maxIdle, maxOpen)
 
 	return db, err
 }
 
 // NewDB create a new DB instance
-func NewDB() *DB {
-	return &DB{
-		tables:        make(map[string]*Table),
+func NewDB(opts ...DBOption) *DB {
+	db := &DB{
 		InitialModels: 20,
+		cache:         newCache(DefaultStmtCacheSize),
+	}
+	for _, opt := range opts {
+		opt(db)
 	}
+
+	return db
 }
 
 // Connect to database server
@@ -53,133 +87,533 @@ func (db *DB) Connect(driver Driver, dsn string, maxIdle, maxOpen int) error {
 func (db *DB) Use(driver Driver, db_ *sql.DB) error {
 	db.driver = driver
 	db.DB = db_
-	db.cache = newCache()
 
 	return nil
 }
 
+// Close close all cached statements, replicas and the database, statements in
+// use are closed after released
+func (db *DB) Close() error {
+	err := db.replicas.close()
+	db.cache.Close()
+
+	if e := db.DB.Close(); e != nil {
+		return e
+	}
+	return err
+}
+
+// CacheStats return the statistics of statement cache
+func (db *DB) CacheStats() CacheStats {
+	return db.cache.Stats()
+}
+
 func (db *DB) Driver() Driver {
 	return db.driver
 }
 
 // Table return infomation of given model
-// if table not exist, do parse and save it
+// if table not exist, do parse and save it. It panics if the model has more
+// than 64 fields and use version, soft delete or auto timestamp fields, which
+// are represented by uint64 bits.
 func (db *DB) Table(model Model) *Table {
+	model, _ = unwrapOps(model)
 	table := model.Table()
-	t, has := db.tables[table]
+	t, has := db.tables.Load(table)
 	if !has {
-		t = parseModel(model, db)
-		db.tables[table] = t
+		nt, err := parseModel(model, db)
+		if err != nil {
+			panic(err)
+		}
+		if nt.cache != nil {
+			nt.cache = db.cache // statements of all tables are limited by the cache of DB
+		}
+		t, _ = db.tables.LoadOrStore(table, nt)
+	}
+
+	return t.(*Table)
+}
+
+// stmt get cached statement for the key, operators attached to model are used,
+// arguments of IN fields are expanded
+func (db *DB) stmt(ctx context.Context, model Model, key stmtKey, args []interface{}) (Stmt, []interface{}, error) {
+	t := db.Table(model)
+	t.scopeKey(model, &key)
+	_, key.ops = unwrapOps(model)
+	args, err := key.expandIn(args)
+	if err != nil {
+		return nil, nil, err
 	}
+	args = t.scopeArgs(key, args, db.now)
+	args = t.autoArgs(key, args, db.now)
 
-	return t
+	stmt, err := t.keyStmt(ctx, db, key)
+	return stmt, args, err
 }
 
 func (db *DB) Insert(model Model, fields uint64, resType ResultType) (int64, error) {
-	return db.ArgsInsert(model, fields, resType, FieldVals(model, fields)...)
+	return db.InsertContext(context.Background(), model, fields, resType)
 }
 
 func (db *DB) ArgsInsert(model Model, fields uint64, resType ResultType, args ...interface{}) (int64, error) {
-	stmt, err := db.Table(model).StmtInsert(db, fields)
+	return db.ArgsInsertContext(context.Background(), model, fields, resType, args...)
+}
+
+func (db *DB) InsertContext(ctx context.Context, model Model, fields uint64, resType ResultType) (int64, error) {
+	if err := beforeHook(ctx, db, model, INSERT); err != nil {
+		return 0, err
+	}
 
-	return Exec(stmt, err, resType, args...)
+	fields = db.stamp(model, INSERT, fields)
+	return db.insert(ctx, model, fields, resType, FieldVals(model, fields))
 }
 
-func (db *DB) Update(model Model, fields, whereFields uint64) (int64, error) {
-	c1, c2 := NumFields(fields), NumFields(whereFields)
-	args := make([]interface{}, c1+c2)
-	model.Vals(fields, args)
-	model.Vals(whereFields, args[c1:])
+func (db *DB) ArgsInsertContext(ctx context.Context, model Model, fields uint64, resType ResultType, args ...interface{}) (int64, error) {
+	if err := beforeHook(ctx, db, model, INSERT); err != nil {
+		return 0, err
+	}
+
+	return db.insert(ctx, model, fields, resType, args)
+}
+
+func (db *DB) insert(ctx context.Context, model Model, fields uint64, resType ResultType, args []interface{}) (int64, error) {
+	stmt, args, err := db.stmt(ctx, model, stmtKey{sqlType: INSERT, fields: fields}, args)
+	n, err := CloseExecContext(ctx, stmt, err, resType, args...)
+
+	return afterHook(ctx, db, model, INSERT, n, err)
+}
+
+// InsertReturning insert model and store values of returnFields such as the
+// generated primary key to model, it use RETURNING clause if driver support it,
+// otherwise only one field can be returned and it's resolved by LastInsertId
+func (db *DB) InsertReturning(model Model, fields, returnFields uint64) error {
+	return db.InsertReturningContext(context.Background(), model, fields, returnFields)
+}
+
+func (db *DB) ArgsInsertReturning(model Model, fields, returnFields uint64, args ...interface{}) error {
+	return db.ArgsInsertReturningContext(context.Background(), model, fields, returnFields, args...)
+}
+
+func (db *DB) InsertReturningContext(ctx context.Context, model Model, fields, returnFields uint64) error {
+	if err := beforeHook(ctx, db, model, INSERT); err != nil {
+		return err
+	}
+
+	fields = db.stamp(model, INSERT, fields)
+	return db.insertReturning(ctx, model, fields, returnFields, FieldVals(model, fields))
+}
+
+func (db *DB) ArgsInsertReturningContext(ctx context.Context, model Model, fields, returnFields uint64, args ...interface{}) error {
+	if err := beforeHook(ctx, db, model, INSERT); err != nil {
+		return err
+	}
+
+	return db.insertReturning(ctx, model, fields, returnFields, args)
+}
+
+func (db *DB) insertReturning(ctx context.Context, model Model, fields, returnFields uint64, args []interface{}) error {
+	stmt, args, err := db.stmt(ctx, model, stmtKey{sqlType: INSERT, fields: fields, returnFields: returnFields}, args)
+	n, err := execReturning(ctx, db, stmt, err, INSERT, model, returnFields, args)
+	_, err = afterHook(ctx, db, model, INSERT, n, err)
+
+	return err
+}
+
+// UpdateReturning update rows and store values of returnFields in the first
+// updated row to model, the count of updated rows was returned, it's only
+// available if driver support RETURNING clause
+func (db *DB) UpdateReturning(model Model, fields, whereFields, returnFields uint64) (int64, error) {
+	return db.UpdateReturningContext(context.Background(), model, fields, whereFields, returnFields)
+}
+
+func (db *DB) ArgsUpdateReturning(model Model, fields, whereFields, returnFields uint64, args ...interface{}) (int64, error) {
+	return db.ArgsUpdateReturningContext(context.Background(), model, fields, whereFields, returnFields, args...)
+}
+
+func (db *DB) UpdateReturningContext(ctx context.Context, model Model, fields, whereFields, returnFields uint64) (int64, error) {
+	if err := beforeHook(ctx, db, model, UPDATE); err != nil {
+		return 0, err
+	}
+
+	fields = db.stamp(model, UPDATE, fields)
+	version := db.Table(model).version
+	return db.updateReturning(ctx, model, fields, whereFields, returnFields, versionArgs(model, version, fields, whereFields), returnFields&version == 0)
+}
+
+func (db *DB) ArgsUpdateReturningContext(ctx context.Context, model Model, fields, whereFields, returnFields uint64, args ...interface{}) (int64, error) {
+	if err := beforeHook(ctx, db, model, UPDATE); err != nil {
+		return 0, err
+	}
+
+	return db.updateReturning(ctx, model, fields, whereFields, returnFields, args, false)
+}
+
+func (db *DB) updateReturning(ctx context.Context, model Model, fields, whereFields, returnFields uint64, args []interface{}, incr bool) (int64, error) {
+	t := db.Table(model)
+	key := versionKey(t, fields, whereFields)
+	key.returnFields = returnFields
+	stmt, args, err := db.stmt(ctx, model, key, args)
+	n, err := execReturning(ctx, db, stmt, err, UPDATE, model, returnFields, args)
+	n, err = versionResult(model, t.version, incr, n, err)
+
+	return afterHook(ctx, db, model, UPDATE, n, err)
+}
+
+// Upsert insert model, if the insert conflict on conflictFields such as primary
+// key or unique key, update the exist row with inserting values of
+// updateFields, the count of affected rows was returned. conflictFields must
+// not be empty, if updateFields is empty, the exist row is keeped.
+func (db *DB) Upsert(model Model, insertFields, conflictFields, updateFields uint64) (int64, error) {
+	return db.UpsertContext(context.Background(), model, insertFields, conflictFields, updateFields)
+}
+
+// ArgsUpsert is similar to Upsert, the arguments are values of insertFields
+func (db *DB) ArgsUpsert(model Model, insertFields, conflictFields, updateFields uint64, args ...interface{}) (int64, error) {
+	return db.ArgsUpsertContext(context.Background(), model, insertFields, conflictFields, updateFields, args...)
+}
+
+func (db *DB) UpsertContext(ctx context.Context, model Model, insertFields, conflictFields, updateFields uint64) (int64, error) {
+	if err := beforeHook(ctx, db, model, INSERT); err != nil {
+		return 0, err
+	}
 
-	return db.ArgsUpdate(model, fields, whereFields, args...)
+	insertFields = db.stamp(model, UPSERT, insertFields)
+	return db.upsert(ctx, model, insertFields, conflictFields, updateFields, FieldVals(model, insertFields))
 }
 
+func (db *DB) ArgsUpsertContext(ctx context.Context, model Model, insertFields, conflictFields, updateFields uint64, args ...interface{}) (int64, error) {
+	if err := beforeHook(ctx, db, model, INSERT); err != nil {
+		return 0, err
+	}
+
+	return db.upsert(ctx, model, insertFields, conflictFields, updateFields, args)
+}
+
+func (db *DB) upsert(ctx context.Context, model Model, insertFields, conflictFields, updateFields uint64, args []interface{}) (int64, error) {
+	stmt, args, err := db.stmt(ctx, model, stmtKey{sqlType: UPSERT, fields: insertFields, whereFields: conflictFields, updateFields: updateFields}, args)
+	n, err := CloseUpdateContext(ctx, stmt, err, args...)
+
+	return afterHook(ctx, db, model, INSERT, n, err)
+}
+
+// BatchInsert insert all models use multiple rows insert sql, models are
+// splitted to chunks if the placeholder count exceed driver limit. The count of
+// affected rows and the generated id of each model are returned, ids are
+// only available if the driver support LastInsertId, for other drivers such as
+// postgres, use BatchInsertReturning.
+func (db *DB) BatchInsert(models []Model, fields uint64) (int64, []int64, error) {
+	return db.BatchInsertContext(context.Background(), models, fields)
+}
+
+func (db *DB) BatchInsertContext(ctx context.Context, models []Model, fields uint64) (int64, []int64, error) {
+	return db.batchInsert(ctx, models, fields, 0)
+}
+
+// BatchInsertReturning is similar to BatchInsert, values of returnFields such as
+// the generated primary key are stored to each model, it use RETURNING clause
+// if driver support it, otherwise only one field can be returned and it's
+// resolved by LastInsertId
+func (db *DB) BatchInsertReturning(models []Model, fields, returnFields uint64) (int64, error) {
+	return db.BatchInsertReturningContext(context.Background(), models, fields, returnFields)
+}
+
+func (db *DB) BatchInsertReturningContext(ctx context.Context, models []Model, fields, returnFields uint64) (int64, error) {
+	n, _, err := db.batchInsert(ctx, models, fields, returnFields)
+	return n, err
+}
+
+func (db *DB) batchInsert(ctx context.Context, models []Model, fields, returnFields uint64) (int64, []int64, error) {
+	for _, model := range models {
+		if err := beforeHook(ctx, db, model, INSERT); err != nil {
+			return 0, nil, err
+		}
+		fields = db.stamp(model, BATCHINSERT, fields)
+	}
+
+	n, ids, err := batchInsert(ctx, db, models, fields, returnFields, func(key stmtKey, args []interface{}) (Stmt, []interface{}, error) {
+		return db.stmt(ctx, models[0], key, args)
+	})
+	for i := 0; i < len(models) && err == nil; i++ {
+		_, err = afterHook(ctx, db, models[i], INSERT, n, nil)
+	}
+
+	return n, ids, err
+}
+
+// Update update rows matched where fields. If model is versioned, the version
+// field is excluded from fields and whereFields, it's increased by the update
+// and the current value is added as condition, ErrVersionConflict is returned
+// if no rows is affected, and the version field of model is increased on
+// success.
+func (db *DB) Update(model Model, fields, whereFields uint64) (int64, error) {
+	return db.UpdateContext(context.Background(), model, fields, whereFields)
+}
+
+// ArgsUpdate is similar to Update, if model is versioned, the current version
+// must be the last argument, the version field of model is not changed.
 func (db *DB) ArgsUpdate(model Model, fields, whereFields uint64, args ...interface{}) (int64, error) {
-	stmt, err := db.Table(model).StmtUpdate(db, fields, whereFields)
+	return db.ArgsUpdateContext(context.Background(), model, fields, whereFields, args...)
+}
+
+func (db *DB) UpdateContext(ctx context.Context, model Model, fields, whereFields uint64) (int64, error) {
+	if err := beforeHook(ctx, db, model, UPDATE); err != nil {
+		return 0, err
+	}
+
+	fields = db.stamp(model, UPDATE, fields)
+	version := db.Table(model).version
+	return db.update(ctx, model, fields, whereFields, versionArgs(model, version, fields, whereFields), true)
+}
+
+func (db *DB) ArgsUpdateContext(ctx context.Context, model Model, fields, whereFields uint64, args ...interface{}) (int64, error) {
+	if err := beforeHook(ctx, db, model, UPDATE); err != nil {
+		return 0, err
+	}
 
-	return Update(stmt, err, args...)
+	return db.update(ctx, model, fields, whereFields, args, false)
+}
+
+// update execute update, if model is versioned, the version field of model is
+// increased after success if incr is true
+func (db *DB) update(ctx context.Context, model Model, fields, whereFields uint64, args []interface{}, incr bool) (int64, error) {
+	t := db.Table(model)
+	stmt, args, err := db.stmt(ctx, model, versionKey(t, fields, whereFields), args)
+	n, err := CloseUpdateContext(ctx, stmt, err, args...)
+	n, err = versionResult(model, t.version, incr, n, err)
+
+	return afterHook(ctx, db, model, UPDATE, n, err)
 }
 
 func (db *DB) Delete(model Model, whereFields uint64) (int64, error) {
-	return db.ArgsDelete(model, whereFields, FieldVals(model, whereFields)...)
+	return db.DeleteContext(context.Background(), model, whereFields)
 }
 
 func (db *DB) ArgsDelete(model Model, whereFields uint64, args ...interface{}) (int64, error) {
-	stmt, err := db.Table(model).StmtDelete(db, whereFields)
+	return db.ArgsDeleteContext(context.Background(), model, whereFields, args...)
+}
 
-	return Update(stmt, err, args...)
+func (db *DB) DeleteContext(ctx context.Context, model Model, whereFields uint64) (int64, error) {
+	if err := beforeHook(ctx, db, model, DELETE); err != nil {
+		return 0, err
+	}
+
+	return db.delete(ctx, model, whereFields, whereVals(model, whereFields))
+}
+
+func (db *DB) ArgsDeleteContext(ctx context.Context, model Model, whereFields uint64, args ...interface{}) (int64, error) {
+	if err := beforeHook(ctx, db, model, DELETE); err != nil {
+		return 0, err
+	}
+
+	return db.delete(ctx, model, whereFields, args)
+}
+
+func (db *DB) delete(ctx context.Context, model Model, whereFields uint64, args []interface{}) (int64, error) {
+	stmt, args, err := db.stmt(ctx, model, stmtKey{sqlType: DELETE, whereFields: whereFields}, args)
+	n, err := CloseUpdateContext(ctx, stmt, err, args...)
+
+	return afterHook(ctx, db, model, DELETE, n, err)
 }
 
 // One select one row from database
 func (db *DB) One(model Model, fields, whereFields uint64) error {
-	return db.ArgsOne(model, fields, whereFields, FieldVals(model, whereFields))
+	return db.OneContext(context.Background(), model, fields, whereFields)
 }
 
 func (db *DB) ArgsOne(model Model, fields, whereFields uint64, args []interface{}, ptrs ...interface{}) error {
-	stmt, err := db.Table(model).StmtOne(db, fields, whereFields)
-	scanner := Query(stmt, err, args...)
-	defer scanner.Close()
+	return db.ArgsOneContext(context.Background(), model, fields, whereFields, args, ptrs...)
+}
 
-	if len(ptrs) == 0 {
-		ptrs = FieldPtrs(model, fields)
-	}
-	return scanner.One(ptrs...)
+func (db *DB) OneContext(ctx context.Context, model Model, fields, whereFields uint64) error {
+	return db.ArgsOneContext(ctx, model, fields, whereFields, whereVals(model, whereFields))
 }
 
-func (db *DB) Limit(store Store, model Model, fields, whereFields uint64, start, count int64) error {
-	args := FieldVals(model, whereFields, start, count)
+func (db *DB) ArgsOneContext(ctx context.Context, model Model, fields, whereFields uint64, args []interface{}, ptrs ...interface{}) error {
+	return db.ArgsOrderOneContext(ctx, model, fields, whereFields, Order{}, args, ptrs...)
+}
 
-	return db.ArgsLimit(store, model, fields, whereFields, args...)
+func (db *DB) Limit(store Store, model Model, fields, whereFields uint64, start, count int64) error {
+	return db.LimitContext(context.Background(), store, model, fields, whereFields, start, count)
 }
 
 // The last two arguments must be "start" and "count" of limition with type "int"
 func (db *DB) ArgsLimit(store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
-	argc := len(args)
-	if argc < 2 {
-		return fmt.Errorf("ArgsLimit need at least two parameters, but only got %d", argc)
+	return db.ArgsLimitContext(context.Background(), store, model, fields, whereFields, args...)
+}
+
+func (db *DB) LimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, start, count int64) error {
+	args := whereVals(model, whereFields, start, count)
+
+	return db.ArgsLimitContext(ctx, store, model, fields, whereFields, args...)
+}
+
+func (db *DB) ArgsLimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
+	return db.ArgsOrderLimitContext(ctx, store, model, fields, whereFields, Order{}, args...)
+}
+
+func (db *DB) All(store Store, model Model, fields, whereFields uint64) error {
+	return db.AllContext(context.Background(), store, model, fields, whereFields)
+}
+
+func (db *DB) ArgsAll(store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
+	return db.ArgsAllContext(context.Background(), store, model, fields, whereFields, args...)
+}
+
+func (db *DB) AllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64) error {
+	return db.ArgsAllContext(ctx, store, model, fields, whereFields, whereVals(model, whereFields)...)
+}
+
+func (db *DB) ArgsAllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
+	return db.ArgsOrderAllContext(ctx, store, model, fields, whereFields, Order{}, args...)
+}
+
+// OrderOne is similar to One, but rows are sorted by order
+func (db *DB) OrderOne(model Model, fields, whereFields uint64, order Order) error {
+	return db.OrderOneContext(context.Background(), model, fields, whereFields, order)
+}
+
+func (db *DB) ArgsOrderOne(model Model, fields, whereFields uint64, order Order, args []interface{}, ptrs ...interface{}) error {
+	return db.ArgsOrderOneContext(context.Background(), model, fields, whereFields, order, args, ptrs...)
+}
+
+func (db *DB) OrderOneContext(ctx context.Context, model Model, fields, whereFields uint64, order Order) error {
+	return db.ArgsOrderOneContext(ctx, model, fields, whereFields, order, whereVals(model, whereFields))
+}
+
+func (db *DB) ArgsOrderOneContext(ctx context.Context, model Model, fields, whereFields uint64, order Order, args []interface{}, ptrs ...interface{}) error {
+	stmt, args, err := db.reader().stmt(ctx, model, stmtKey{sqlType: ONE, fields: fields, whereFields: whereFields, order: order}, args)
+	scanner := QueryContext(ctx, stmt, err, args...)
+	defer scanner.Close()
+
+	if len(ptrs) != 0 {
+		return scanner.One(ptrs...)
 	}
-	offset, err := utils.ConvToInt64(args[argc-2])
-	if err != nil {
+	if err = scanner.One(FieldPtrs(model, fields)...); err != nil {
 		return err
 	}
-	count, err := utils.ConvToInt64(args[argc-1])
+	return afterScan(model)
+}
+
+// OrderLimit is similar to Limit, but rows are sorted by order
+func (db *DB) OrderLimit(store Store, model Model, fields, whereFields uint64, order Order, start, count int64) error {
+	return db.OrderLimitContext(context.Background(), store, model, fields, whereFields, order, start, count)
+}
+
+func (db *DB) ArgsOrderLimit(store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error {
+	return db.ArgsOrderLimitContext(context.Background(), store, model, fields, whereFields, order, args...)
+}
+
+func (db *DB) OrderLimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, start, count int64) error {
+	args := whereVals(model, whereFields, start, count)
+
+	return db.ArgsOrderLimitContext(ctx, store, model, fields, whereFields, order, args...)
+}
+
+func (db *DB) ArgsOrderLimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error {
+	count, err := limitArgs(db.Driver(), args)
 	if err != nil {
 		return err
 	}
 
-	arg1, arg2 := db.driver.ParamLimit(int(offset), int(count))
-	args[argc-2], args[argc-1] = arg1, arg2
-
-	stmt, err := db.Table(model).StmtLimit(db, fields, whereFields)
-	scanner := Query(stmt, err, args...)
+	stmt, args, err := db.reader().stmt(ctx, model, stmtKey{sqlType: LIMIT, fields: fields, whereFields: whereFields, order: order}, args)
+	scanner := QueryContext(ctx, stmt, err, args...)
 	defer scanner.Close()
 
-	return scanner.Limit(store, int(count))
+	return scanner.Limit(store, count)
 }
 
-func (db *DB) All(store Store, model Model, fields, whereFields uint64) error {
-	return db.ArgsAll(store, model, fields, whereFields, FieldVals(model, whereFields)...)
+// OrderAll is similar to All, but rows are sorted by order
+func (db *DB) OrderAll(store Store, model Model, fields, whereFields uint64, order Order) error {
+	return db.OrderAllContext(context.Background(), store, model, fields, whereFields, order)
 }
 
-func (db *DB) ArgsAll(store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
-	stmt, err := db.Table(model).StmtAll(db, fields, whereFields)
-	scanner := Query(stmt, err, args...)
+func (db *DB) ArgsOrderAll(store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error {
+	return db.ArgsOrderAllContext(context.Background(), store, model, fields, whereFields, order, args...)
+}
+
+func (db *DB) OrderAllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order) error {
+	return db.ArgsOrderAllContext(ctx, store, model, fields, whereFields, order, whereVals(model, whereFields)...)
+}
+
+func (db *DB) ArgsOrderAllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error {
+	stmt, args, err := db.reader().stmt(ctx, model, stmtKey{sqlType: ALL, fields: fields, whereFields: whereFields, order: order}, args)
+	scanner := QueryContext(ctx, stmt, err, args...)
 	defer scanner.Close()
 
 	return scanner.All(store, db.InitialModels)
 }
 
+// Iter query rows like OrderAll, but the rows are not stored, iterate rows by
+// Scanner's Next/Scan or Each for large result set, the Scanner must be closed
+// if rows is not iterated to the end
+func (db *DB) Iter(model Model, fields, whereFields uint64, order Order) Scanner {
+	return db.IterContext(context.Background(), model, fields, whereFields, order)
+}
+
+func (db *DB) ArgsIter(model Model, fields, whereFields uint64, order Order, args ...interface{}) Scanner {
+	return db.ArgsIterContext(context.Background(), model, fields, whereFields, order, args...)
+}
+
+func (db *DB) IterContext(ctx context.Context, model Model, fields, whereFields uint64, order Order) Scanner {
+	return db.ArgsIterContext(ctx, model, fields, whereFields, order, whereVals(model, whereFields)...)
+}
+
+func (db *DB) ArgsIterContext(ctx context.Context, model Model, fields, whereFields uint64, order Order, args ...interface{}) Scanner {
+	stmt, args, err := db.reader().stmt(ctx, model, stmtKey{sqlType: ALL, fields: fields, whereFields: whereFields, order: order}, args)
+
+	return QueryContext(ctx, stmt, err, args...)
+}
+
+// Page select at most count rows after the cursor sorted by order, it use
+// keyset condition like 'WHERE (a, b) > (?, ?)' instead of OFFSET, so it's
+// efficient for deep pages. The cursor of first page is "", the returned
+// cursor should be used for next page, it's "" if there is no more rows.
+//
+// Order fields must be part of fields and should be unique together, all of
+// them must be sorted in same direction. The values of cursor are decoded into
+// order fields of model.
+func (db *DB) Page(store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int) (string, error) {
+	return db.PageContext(context.Background(), store, model, fields, whereFields, order, cursor, count)
+}
+
+func (db *DB) ArgsPage(store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int, args ...interface{}) (string, error) {
+	return db.ArgsPageContext(context.Background(), store, model, fields, whereFields, order, cursor, count, args...)
+}
+
+func (db *DB) PageContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int) (string, error) {
+	return db.ArgsPageContext(ctx, store, model, fields, whereFields, order, cursor, count, whereVals(model, whereFields)...)
+}
+
+func (db *DB) ArgsPageContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int, args ...interface{}) (string, error) {
+	key, args, err := pageArgs(model, fields, whereFields, order, cursor, count, args)
+	if err != nil {
+		return "", err
+	}
+
+	stmt, args, err := db.reader().stmt(ctx, model, key, args)
+	scanner := QueryContext(ctx, stmt, err, args...)
+	defer scanner.Close()
+
+	return scanPage(scanner, store, fields, order, count)
+}
+
 // Count return count of rows for model, arguments was extracted from Model
 func (db *DB) Count(model Model, whereFields uint64) (count int64, err error) {
-	return db.ArgsCount(model, whereFields, FieldVals(model, whereFields)...)
+	return db.CountContext(context.Background(), model, whereFields)
 }
 
 // ArgsCount return count of rows for model use custome arguments
 func (db *DB) ArgsCount(model Model, whereFields uint64, args ...interface{}) (count int64, err error) {
-	t := db.Table(model)
+	return db.ArgsCountContext(context.Background(), model, whereFields, args...)
+}
 
-	stmt, err := t.StmtCount(db, whereFields)
-	scanner := Query(stmt, err, args...)
+func (db *DB) CountContext(ctx context.Context, model Model, whereFields uint64) (count int64, err error) {
+	return db.ArgsCountContext(ctx, model, whereFields, whereVals(model, whereFields)...)
+}
+
+func (db *DB) ArgsCountContext(ctx context.Context, model Model, whereFields uint64, args ...interface{}) (count int64, err error) {
+	stmt, args, err := db.reader().stmt(ctx, model, stmtKey{sqlType: COUNT, whereFields: whereFields}, args)
+	scanner := QueryContext(ctx, stmt, err, args...)
 	defer scanner.Close()
 
 	err = scanner.One(&count)
@@ -188,30 +622,39 @@ func (db *DB) ArgsCount(model Model, whereFields uint64, args ...interface{}) (c
 }
 
 func (db *DB) IncrBy(model Model, fields, whereFields uint64, counts ...int) (int64, error) {
-	cntLen := len(counts)
-	args := make([]interface{}, NumFields(whereFields)+cntLen)
-	for i, count := range counts {
-		args[i] = count
-	}
-	model.Vals(whereFields, args[cntLen:])
-
-	return db.ArgsIncrBy(model, fields, whereFields, args...)
+	return db.IncrByContext(context.Background(), model, fields, whereFields, counts...)
 }
 
 func (db *DB) ArgsIncrBy(model Model, fields, whereFields uint64, args ...interface{}) (int64, error) {
-	stmt, err := db.Table(model).StmtIncrBy(db, fields, whereFields)
+	return db.ArgsIncrByContext(context.Background(), model, fields, whereFields, args...)
+}
 
-	return Update(stmt, err, args...)
+func (db *DB) IncrByContext(ctx context.Context, model Model, fields, whereFields uint64, counts ...int) (int64, error) {
+	return db.ArgsIncrByContext(ctx, model, fields, whereFields, incrByArgs(model, whereFields, counts)...)
+}
+
+func (db *DB) ArgsIncrByContext(ctx context.Context, model Model, fields, whereFields uint64, args ...interface{}) (int64, error) {
+	stmt, args, err := db.stmt(ctx, model, stmtKey{sqlType: INCRBY, fields: fields, whereFields: whereFields}, args)
+
+	return CloseUpdateContext(ctx, stmt, err, args...)
 }
 
 func (db *DB) Exists(model Model, field, whereFields uint64) (bool, error) {
-	return db.ArgsExists(model, field, whereFields, FieldVals(model, whereFields)...)
+	return db.ExistsContext(context.Background(), model, field, whereFields)
 }
 
 func (db *DB) ArgsExists(model Model, field, whereFields uint64, args ...interface{}) (exist bool, err error) {
-	stmt, err := db.Table(model).StmtExists(db, field, whereFields)
+	return db.ArgsExistsContext(context.Background(), model, field, whereFields, args...)
+}
+
+func (db *DB) ExistsContext(ctx context.Context, model Model, field, whereFields uint64) (bool, error) {
+	return db.ArgsExistsContext(ctx, model, field, whereFields, whereVals(model, whereFields)...)
+}
 
-	scanner := Query(stmt, err, args...)
+func (db *DB) ArgsExistsContext(ctx context.Context, model Model, field, whereFields uint64, args ...interface{}) (exist bool, err error) {
+	stmt, args, err := db.reader().stmt(ctx, model, stmtKey{sqlType: EXISTS, fields: field, whereFields: whereFields}, args)
+
+	scanner := QueryContext(ctx, stmt, err, args...)
 	defer scanner.Close()
 	err = scanner.One(&exist)
 
@@ -219,9 +662,7 @@ func (db *DB) ArgsExists(model Model, field, whereFields uint64, args ...interfa
 }
 
 func (db *DB) ExecById(sqlid uint64, resTyp ResultType, args ...interface{}) (int64, error) {
-	stmt, err := db.StmtById(sqlid)
-
-	return Exec(stmt, err, resTyp, args...)
+	return db.ExecByIdContext(context.Background(), sqlid, resTyp, args...)
 }
 
 func (db *DB) UpdateById(sqlid uint64, args ...interface{}) (int64, error) {
@@ -229,49 +670,126 @@ func (db *DB) UpdateById(sqlid uint64, args ...interface{}) (int64, error) {
 }
 
 func (db *DB) QueryById(sqlid uint64, args ...interface{}) Scanner {
-	stmt, err := db.StmtById(sqlid)
+	return db.QueryByIdContext(context.Background(), sqlid, args...)
+}
+
+func (db *DB) ExecByIdContext(ctx context.Context, sqlid uint64, resTyp ResultType, args ...interface{}) (int64, error) {
+	stmt, err := db.stmtById(ctx, sqlid)
+
+	return CloseExecContext(ctx, stmt, err, resTyp, args...)
+}
+
+func (db *DB) UpdateByIdContext(ctx context.Context, sqlid uint64, args ...interface{}) (int64, error) {
+	return db.ExecByIdContext(ctx, sqlid, RES_ROWS, args...)
+}
+
+func (db *DB) QueryByIdContext(ctx context.Context, sqlid uint64, args ...interface{}) Scanner {
+	stmt, err := db.reader().stmtById(ctx, sqlid)
 
-	return Query(stmt, err, args...)
+	return QueryContext(ctx, stmt, err, args...)
 }
 
-func (db *DB) prepare(sql string) (Stmt, error) {
+func (db *DB) prepare(ctx context.Context, sql string) (Stmt, error) {
 	sql = db.driver.Prepare(sql)
 	sqlPrinter(sql)
-	stmt, err := db.DB.Prepare(sql)
-	return WrapStmt(true, stmt, err)
+	stmt, err := db.DB.PrepareContext(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	return withHook(db, stmt, stmtInfo{sql: sql, sqlType: RAW}), nil
 }
 
 func (db *DB) Query(sql string, args ...interface{}) Scanner {
-	stmt, err := db.prepare(sql)
-	return Query(stmt, err, args...)
+	return db.QueryContext(context.Background(), sql, args...)
 }
 
 func (db *DB) Exec(sql string, resTyp ResultType, args ...interface{}) (int64, error) {
-	stmt, err := db.prepare(sql)
-	return CloseExec(stmt, err, resTyp, args...)
+	return db.ExecContext(context.Background(), sql, resTyp, args...)
 }
 
 func (db *DB) ExecUpdate(sql string, args ...interface{}) (int64, error) {
 	return db.Exec(sql, RES_ROWS, args...)
 }
 
+func (db *DB) QueryContext(ctx context.Context, sql string, args ...interface{}) Scanner {
+	stmt, err := db.prepare(ctx, sql)
+	return QueryContext(ctx, stmt, err, args...)
+}
+
+func (db *DB) ExecContext(ctx context.Context, sql string, resTyp ResultType, args ...interface{}) (int64, error) {
+	stmt, err := db.prepare(ctx, sql)
+	return CloseExecContext(ctx, stmt, err, resTyp, args...)
+}
+
+func (db *DB) ExecUpdateContext(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return db.ExecContext(ctx, sql, RES_ROWS, args...)
+}
+
 var emptyTX = &Tx{}
 
 func (db *DB) Begin() (*Tx, error) {
-	tx, err := db.DB.Begin()
+	return db.BeginTx(context.Background(), nil)
+}
+
+// BeginTx start a transaction with the context and options, the transaction
+// will be rollbacked if context is canceled before it's committed
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	ctx, span := db.startTx(ctx)
+	tx, err := db.DB.BeginTx(ctx, opts)
 	if err != nil {
+		if span != nil {
+			span.End(err)
+		}
 		return emptyTX, err
 	}
 
-	return newTx(tx, db), nil
+	var o TxOptions
+	if opts != nil {
+		o = *opts
+	}
+	t := newTx(ctx, tx, db, o)
+	t.span = span
+	return t, nil
+}
+
+// BeginWith start a transaction with the isolation level and read-only flag
+func (db *DB) BeginWith(opts TxOptions) (*Tx, error) {
+	return db.BeginTx(context.Background(), &opts)
 }
 
 func (db *DB) TxDo(do func(*Tx) error) error {
-	tx, err := db.Begin()
+	return db.TxDoContext(context.Background(), do)
+}
+
+// TxDoContext is similar to TxDo, but start the transaction with the context
+func (db *DB) TxDoContext(ctx context.Context, do func(*Tx) error) error {
+	return db.TxDoWithContext(ctx, TxOptions{}, do)
+}
+
+// TxDoWith is similar to TxDo, but start the transaction with the options
+func (db *DB) TxDoWith(opts TxOptions, do func(*Tx) error) error {
+	return db.TxDoWithContext(context.Background(), opts, do)
+}
+
+// TxDoWithContext is similar to TxDoWith, but start the transaction with the
+// context, the error of commit is also returned. If the function panics, the
+// transaction is rollbacked and the panic is propagated.
+func (db *DB) TxDoWithContext(ctx context.Context, opts TxOptions, do func(*Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, &opts)
 	if err != nil {
 		return err
 	}
-	defer tx.Close()
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Success(false)
+			tx.Close()
+			panic(p)
+		}
+		if e := tx.Close(); err == nil {
+			err = e
+		}
+	}()
 
 	err = do(tx)
 	tx.Success(err == nil)
@@ -279,7 +797,173 @@ func (db *DB) TxDo(do func(*Tx) error) error {
 }
 
 func (db *DB) StmtById(sqlid uint64) (Stmt, error) {
-	stmt, err := db.cache.StmtById(db, sqlid)
+	return db.StmtByIdContext(context.Background(), sqlid)
+}
+
+// StmtByIdContext get the cached statement by sql id, the statement is owned by
+// cache, it needn't be closed
+func (db *DB) StmtByIdContext(ctx context.Context, sqlid uint64) (Stmt, error) {
+	return db.cache.Share(db.stmtById(ctx, sqlid))
+}
+
+// stmtById get the cached statement by sql id for internal use, it must be
+// closed after used to release the reference to cached statement
+func (db *DB) stmtById(ctx context.Context, sqlid uint64) (Stmt, error) {
+	return db.cache.AcquireStmtById(ctx, db, sqlid)
+}
+
+// batchInsert split models to chunks, and insert each chunk with the statement
+// returned from stmtOf, which also return the final arguments of the chunk, the
+// statement is closed after executed. If returnFields is not empty, values of
+// them are stored to models by RETURNING clause, or resolved by LastInsertId if
+// there is only one field. The ids of models are returned if the driver
+// support LastInsertId.
+func batchInsert(ctx context.Context, exec Executor, models []Model, fields, returnFields uint64, stmtOf func(stmtKey, []interface{}) (Stmt, []interface{}, error)) (int64, []int64, error) {
+	numFields := NumFields(fields)
+	if len(models) == 0 || numFields == 0 {
+		return 0, nil, nil
+	}
+
+	driver, t := exec.Driver(), exec.Table(models[0])
+	returning := returnFields != 0 && driver.SQLReturning(t.Cols(returnFields).Names()) != ""
+	if returnFields != 0 && !returning && NumFields(returnFields) != 1 {
+		return 0, nil, ErrReturningUnsupported
+	}
+
+	// auto timestamp fields are also placeholders of each row
+	params := numFields + NumFields(t.autoFields(BATCHINSERT)&^fields)
+	chunk := len(models)
+	if max := driver.MaxParams(); max > 0 && chunk*params > max {
+		chunk = max / params
+		if chunk == 0 {
+			return 0, nil, fmt.Errorf("%d fields exceed the max placeholder count %d", params, max)
+		}
+	}
+
+	var (
+		affected int64
+		ids      []int64
+	)
+	for len(models) > 0 {
+		rows := chunk
+		if rows > len(models) {
+			rows = len(models)
+		}
+		args := make([]interface{}, rows*numFields)
+		for i, model := range models[:rows] {
+			model.Vals(fields, args[i*numFields:(i+1)*numFields])
+		}
+
+		stmt, args, err := stmtOf(stmtKey{sqlType: BATCHINSERT, fields: fields, rows: rows, returnFields: returnFields}, args)
+		if err != nil {
+			return affected, ids, err
+		}
+		n, chunkIds, err := batchExec(ctx, driver, stmt, models[:rows], returnFields, returning, args)
+		affected += n
+		ids = append(ids, chunkIds...)
+		if err != nil {
+			return affected, ids, err
+		}
+		models = models[rows:]
+	}
+
+	return affected, ids, nil
+}
+
+// batchExec execute the statement for a chunk of models, values of returnFields
+// in returned rows are stored to models in order, or ids of models are
+// resolved by LastInsertId
+func batchExec(ctx context.Context, driver Driver, stmt Stmt, models []Model, returnFields uint64, returning bool, args []interface{}) (int64, []int64, error) {
+	defer stmt.Close()
+
+	if returning {
+		rows, err := stmt.QueryContext(ctx, args...)
+		if err != nil {
+			return 0, nil, err
+		}
+		defer rows.Close()
+
+		var n int64
+		for ; rows.Next(); n++ {
+			if int(n) < len(models) {
+				if err = rows.Scan(FieldPtrs(models[n], returnFields)...); err != nil {
+					return n, nil, err
+				}
+			}
+		}
+		return n, nil, rows.Err()
+	}
+
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return 0, nil, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		if returnFields != 0 {
+			return n, nil, ErrReturningUnsupported
+		}
+		return n, nil, nil
+	}
+
+	first := driver.FirstInsertId(id, len(models))
+	ids := make([]int64, len(models))
+	for i := range ids {
+		ids[i] = first + int64(i)
+		if returnFields != 0 {
+			if err = assignId(FieldPtrs(models[i], returnFields)[0], ids[i]); err != nil {
+				return n, ids, err
+			}
+		}
+	}
+	return n, ids, nil
+}
+
+func updateArgs(model Model, fields, whereFields uint64) []interface{} {
+	_, ops := unwrapOps(model)
+	whereFields = ops.ArgFields(whereFields)
+	c1, c2 := NumFields(fields), NumFields(whereFields)
+	args := make([]interface{}, c1+c2)
+	model.Vals(fields, args)
+	model.Vals(whereFields, args[c1:])
+
+	return args
+}
+
+func incrByArgs(model Model, whereFields uint64, counts []int) []interface{} {
+	_, ops := unwrapOps(model)
+	whereFields = ops.ArgFields(whereFields)
+	cntLen := len(counts)
+	args := make([]interface{}, NumFields(whereFields)+cntLen)
+	for i, count := range counts {
+		args[i] = count
+	}
+	model.Vals(whereFields, args[cntLen:])
+
+	return args
+}
+
+// limitArgs convert the last two arguments "start" and "count" to driver
+// specific limit parameters, the row count was returned
+func limitArgs(driver Driver, args []interface{}) (int, error) {
+	argc := len(args)
+	if argc < 2 {
+		return 0, fmt.Errorf("ArgsLimit need at least two parameters, but only got %d", argc)
+	}
+	offset, err := utils.ConvToInt64(args[argc-2])
+	if err != nil {
+		return 0, err
+	}
+	count, err := utils.ConvToInt64(args[argc-1])
+	if err != nil {
+		return 0, err
+	}
+
+	args[argc-2], args[argc-1] = driver.ParamLimit(int(offset), int(count))
 
-	return WrapStmt(STMT_NOPCLOSE, stmt, err)
+	return int(count), nil
 }
@@ -11,6 +11,10 @@ import (
 // Only tested for mysql
 var (
 	NonError = errors.New("non error")
+
+	// ErrVersionConflict is returned by Update of versioned models if the row
+	// is modified by others
+	ErrVersionConflict = gomodel.ErrVersionConflict
 )
 
 type (
@@ -124,3 +128,11 @@ func HasAffects(c int64, err, newErr error) error {
 
 	return err
 }
+
+func VersionConflict(err, newErr error) error {
+	if err == ErrVersionConflict {
+		return newErr
+	}
+
+	return err
+}
@@ -0,0 +1,67 @@
+// Package dbtrace adapt OpenTelemetry tracer to gomodel.Tracer
+package dbtrace
+
+import (
+	"context"
+
+	"github.com/cosiner/gomodel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	// Tracer start OpenTelemetry spans for gomodel, set it to DB.Tracer
+	Tracer struct {
+		Tracer trace.Tracer
+		// System is the value of attribute 'db.system' such as mysql, postgresql
+		System string
+		// OmitStatement don't record sql as attribute 'db.statement'
+		OmitStatement bool
+	}
+
+	span struct {
+		trace.Span
+	}
+)
+
+// NewTracer create a Tracer use the OpenTelemetry tracer
+func NewTracer(tracer trace.Tracer, system string) *Tracer {
+	return &Tracer{
+		Tracer: tracer,
+		System: system,
+	}
+}
+
+func (t *Tracer) Start(ctx context.Context, info gomodel.SpanInfo) (context.Context, gomodel.Span) {
+	attrs := []attribute.KeyValue{attribute.String("db.system", t.System)}
+	kind := trace.SpanKindInternal
+	if !info.Tx {
+		kind = trace.SpanKindClient
+		attrs = append(attrs, attribute.String("db.operation", info.Type.String()))
+		if info.Table != "" {
+			attrs = append(attrs, attribute.String("db.sql.table", info.Table))
+		}
+		if info.ById {
+			attrs = append(attrs, attribute.Int64("gomodel.sql_id", int64(info.SQLId)))
+		}
+		if !t.OmitStatement {
+			attrs = append(attrs, attribute.String("db.statement", info.SQL))
+		}
+	}
+
+	ctx, s := t.Tracer.Start(ctx, info.Name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+	return ctx, span{s}
+}
+
+func (t *Tracer) Inherit(ctx, parent context.Context) context.Context {
+	return trace.ContextWithSpan(ctx, trace.SpanFromContext(parent))
+}
+
+func (s span) End(err error) {
+	if err != nil {
+		s.RecordError(err)
+		s.SetStatus(codes.Error, err.Error())
+	}
+	s.Span.End()
+}
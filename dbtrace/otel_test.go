@@ -0,0 +1,45 @@
+package dbtrace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cosiner/gohper/testing2"
+	"github.com/cosiner/gomodel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	tt := testing2.Wrap(t)
+	rec := tracetest.NewSpanRecorder()
+	tracer := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)).Tracer("gomodel"), "mysql")
+
+	txCtx, tx := tracer.Start(context.Background(), gomodel.SpanInfo{Name: gomodel.SPAN_TX, Tx: true})
+	ctx := tracer.Inherit(context.Background(), txCtx)
+	_, s := tracer.Start(ctx, gomodel.SpanInfo{
+		Name:  gomodel.SPAN_PREFIX + "UPDATE",
+		Table: "user",
+		Type:  gomodel.UPDATE,
+		SQL:   "UPDATE user SET name=? WHERE id=?",
+	})
+	s.End(errors.New("failed"))
+	tx.End(nil)
+
+	spans := rec.Ended()
+	tt.Eq(2, len(spans))
+	stmt := spans[0]
+	tt.Eq("gomodel.UPDATE", stmt.Name())
+	tt.Eq(spans[1].SpanContext().SpanID(), stmt.Parent().SpanID())
+	tt.Eq(codes.Error, stmt.Status().Code)
+	tt.DeepEq([]attribute.KeyValue{
+		attribute.String("db.system", "mysql"),
+		attribute.String("db.operation", "UPDATE"),
+		attribute.String("db.sql.table", "user"),
+		attribute.String("db.statement", "UPDATE user SET name=? WHERE id=?"),
+	}, stmt.Attributes())
+	tt.Eq(1, len(stmt.Events())) // recorded error
+}
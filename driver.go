@@ -8,6 +8,22 @@ type Driver interface {
 	Prepare(sql string) string
 	SQLLimit() string
 	ParamLimit(offset, count int) (int, int)
+	// MaxParams return the max count of placeholders in a sql statement, 0 means
+	// no limit
+	MaxParams() int
+	// SQLUpsert return the clause appended to insert sql to update columns with
+	// the inserting values if conflict on conflictCols, if there is no update
+	// columns, the conflict row should be keeped
+	SQLUpsert(conflictCols, updateCols []string) string
+	// SQLReturning return the clause appended to insert/update sql to return
+	// values of columns, if it's not supported, return ""
+	SQLReturning(cols []string) string
+	// FirstInsertId return the id of first row inserted by a multi-row insert
+	// from the LastInsertId of it, ids of the rows are consecutive
+	FirstInsertId(lastInsertId int64, rows int) int64
+	// Retryable check whether the error is caused by transaction conflicts such
+	// as deadlock, serialization failure, the transaction can be retried
+	Retryable(err error) bool
 	PrimaryKey() string
 	DuplicateKey(err error) string
 	ForeignKey(err error) string
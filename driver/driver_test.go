@@ -0,0 +1,54 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cosiner/gohper/testing2"
+)
+
+func TestUpsert(t *testing.T) {
+	tt := testing2.Wrap(t)
+
+	tt.Eq("ON CONFLICT (id) DO UPDATE SET name=EXCLUDED.name,age=EXCLUDED.age",
+		OnConflict([]string{"id"}, []string{"name", "age"}))
+	tt.Eq("ON CONFLICT (id,name) DO NOTHING", OnConflict([]string{"id", "name"}, nil))
+	tt.Eq("ON DUPLICATE KEY UPDATE name=VALUES(name),age=VALUES(age)",
+		MySQL("mysql").SQLUpsert([]string{"id"}, []string{"name", "age"}))
+	tt.Eq("ON DUPLICATE KEY UPDATE id=id", MySQL("mysql").SQLUpsert([]string{"id"}, nil))
+}
+
+// testMySQLError has the same layout as *mysql.MySQLError
+type testMySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *testMySQLError) Error() string {
+	return fmt.Sprintf("Error %d: %s", e.Number, e.Message)
+}
+
+type testPGError map[byte]string
+
+func (e testPGError) Get(k byte) string {
+	return e[k]
+}
+
+func (e testPGError) Error() string {
+	return e['M']
+}
+
+func TestRetryable(t *testing.T) {
+	tt := testing2.Wrap(t)
+
+	tt.True(MySQL("mysql").Retryable(&testMySQLError{1213, "Deadlock found when trying to get lock; try restarting transaction"}))
+	tt.True(MySQL("mysql").Retryable(fmt.Errorf("update: %w", &testMySQLError{1205, "Lock wait timeout exceeded; try restarting transaction"})))
+	tt.True(!MySQL("mysql").Retryable(&testMySQLError{1062, "Duplicate entry '1' for key 'PRIMARY'"}))
+	tt.True(!MySQL("mysql").Retryable(errors.New("Error 1213: Deadlock found when trying to get lock; try restarting transaction")))
+	tt.True(SQLite3("sqlite3").Retryable(errors.New("database is locked")))
+	tt.True(Postgres("postgres").Retryable(fmt.Errorf("update: %w", testPGError{'C': "40P01", 'M': "deadlock detected"})))
+	tt.True(Postgres("postgres").Retryable(testPGError{'C': "40001"}))
+	tt.True(!Postgres("postgres").Retryable(testPGError{'C': "23505"}))
+	tt.True(!Postgres("postgres").Retryable(errors.New("deadlock detected")))
+}
@@ -2,7 +2,9 @@ package driver
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/cosiner/gomodel"
@@ -55,6 +57,68 @@ func (MySQL) ParamLimit(offset, count int) (int, int) {
 	return offset, count
 }
 
+func (MySQL) MaxParams() int {
+	return 65535
+}
+
+func (MySQL) SQLUpsert(conflictCols, updateCols []string) string {
+	if len(updateCols) == 0 {
+		// conflict key is resolved by mysql itself, just keep the row by a no-op update
+		return fmt.Sprintf("ON DUPLICATE KEY UPDATE %s=%s", conflictCols[0], conflictCols[0])
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("ON DUPLICATE KEY UPDATE ")
+	for i, col := range updateCols {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%s=VALUES(%s)", col, col)
+	}
+	return buf.String()
+}
+
+func (MySQL) SQLReturning(cols []string) string {
+	return ""
+}
+
+// FirstInsertId return lastInsertId, mysql report the id of first row, the
+// ids are consecutive if innodb_autoinc_lock_mode is 0 or 1
+func (MySQL) FirstInsertId(lastInsertId int64, _ int) int64 {
+	return lastInsertId
+}
+
+func (MySQL) Retryable(err error) bool {
+	const (
+		ER_LOCK_WAIT_TIMEOUT = 1205
+		ER_LOCK_DEADLOCK     = 1213
+	)
+	num, is := mysqlErrno(err)
+	return is && (num == ER_LOCK_WAIT_TIMEOUT || num == ER_LOCK_DEADLOCK)
+}
+
+// mysqlErrno find the error number of the first MySQL error in the error
+// chain, it's a struct or pointer to struct has an unsigned integer field
+// 'Number' like *mysql.MySQLError, the driver is not imported
+func mysqlErrno(err error) (uint64, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		v := reflect.ValueOf(err)
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+
+		switch num := v.FieldByName("Number"); num.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return num.Uint(), true
+		}
+	}
+
+	return 0, false
+}
+
 func (MySQL) PrimaryKey() string {
 	return "PRIMARY"
 }
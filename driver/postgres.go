@@ -2,6 +2,7 @@ package driver
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -71,10 +72,40 @@ func (Postgres) ParamLimit(offset, count int) (int, int) {
 	return count, offset
 }
 
+func (Postgres) MaxParams() int {
+	return 65535
+}
+
+func (Postgres) SQLUpsert(conflictCols, updateCols []string) string {
+	return OnConflict(conflictCols, updateCols)
+}
+
+func (Postgres) SQLReturning(cols []string) string {
+	return "RETURNING " + strings.Join(cols, ",")
+}
+
+// FirstInsertId is never used, postgres don't support LastInsertId
+func (Postgres) FirstInsertId(lastInsertId int64, _ int) int64 {
+	return lastInsertId
+}
+
 func (Postgres) PrimaryKey() string {
 	return "PRIMARY"
 }
 
+func (Postgres) Retryable(err error) bool {
+	const (
+		PGERR_SERIALIZATION = "40001"
+		PGERR_DEADLOCK      = "40P01"
+	)
+	e, is := pgError(err)
+	if !is {
+		return false
+	}
+	code := e.Get('C')
+	return code == PGERR_SERIALIZATION || code == PGERR_DEADLOCK
+}
+
 func (p Postgres) DuplicateKey(err error) string {
 	const PGERR_DUPLICATE string = "23505"
 	return p.pgKey(PGERR_DUPLICATE, err)
@@ -89,11 +120,17 @@ type PGError interface {
 	Get(k byte) (v string)
 }
 
+// pgError find the first PGError in the error chain
+func pgError(err error) (PGError, bool) {
+	var e PGError
+	return e, errors.As(err, &e)
+}
+
 func (p Postgres) pgKey(errCode string, err error) string {
 	if err == nil {
 		return ""
 	}
-	e, is := err.(PGError)
+	e, is := pgError(err)
 	if !is || e.Get('C') != errCode {
 		return ""
 	}
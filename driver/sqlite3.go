@@ -2,6 +2,7 @@ package driver
 
 import (
 	"bytes"
+	"strings"
 
 	"github.com/cosiner/gomodel"
 )
@@ -52,6 +53,33 @@ func (SQLite3) ParamLimit(offset, count int) (int, int) {
 	return offset, count
 }
 
+func (SQLite3) MaxParams() int {
+	return 999
+}
+
+func (SQLite3) SQLUpsert(conflictCols, updateCols []string) string {
+	return OnConflict(conflictCols, updateCols)
+}
+
+func (SQLite3) SQLReturning(cols []string) string {
+	return "RETURNING " + strings.Join(cols, ",")
+}
+
+// FirstInsertId calculate the id of first row, sqlite report the rowid of
+// last row
+func (SQLite3) FirstInsertId(lastInsertId int64, rows int) int64 {
+	return lastInsertId - int64(rows) + 1
+}
+
+func (SQLite3) Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	// SQLITE_BUSY, SQLITE_LOCKED
+	s := err.Error()
+	return strings.Contains(s, "database is locked") || strings.Contains(s, "database table is locked")
+}
+
 func (SQLite3) PrimaryKey() string {
 	return ""
 }
@@ -0,0 +1,18 @@
+package driver
+
+import "strings"
+
+// OnConflict create the 'ON CONFLICT' clause of upsert sql for databases
+// support it, such as postgresql and sqlite3
+func OnConflict(conflictCols, updateCols []string) string {
+	clause := "ON CONFLICT (" + strings.Join(conflictCols, ",") + ") "
+	if len(updateCols) == 0 {
+		return clause + "DO NOTHING"
+	}
+
+	sets := make([]string, len(updateCols))
+	for i, col := range updateCols {
+		sets[i] = col + "=EXCLUDED." + col
+	}
+	return clause + "DO UPDATE SET " + strings.Join(sets, ",")
+}
@@ -1,46 +1,123 @@
 package gomodel
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+)
 
 type (
 	Executor interface {
 		Driver() Driver
 		Table(model Model) *Table
 		Prepare(sql string) (*sql.Stmt, error)
+		PrepareContext(ctx context.Context, sql string) (*sql.Stmt, error)
 
 		Insert(model Model, fields uint64, resType ResultType) (int64, error)
 		ArgsInsert(model Model, fields uint64, resType ResultType, args ...interface{}) (int64, error)
+		InsertContext(ctx context.Context, model Model, fields uint64, resType ResultType) (int64, error)
+		ArgsInsertContext(ctx context.Context, model Model, fields uint64, resType ResultType, args ...interface{}) (int64, error)
+
+		InsertReturning(model Model, fields, returnFields uint64) error
+		ArgsInsertReturning(model Model, fields, returnFields uint64, args ...interface{}) error
+		InsertReturningContext(ctx context.Context, model Model, fields, returnFields uint64) error
+		ArgsInsertReturningContext(ctx context.Context, model Model, fields, returnFields uint64, args ...interface{}) error
+
+		UpdateReturning(model Model, fields, whereFields, returnFields uint64) (int64, error)
+		ArgsUpdateReturning(model Model, fields, whereFields, returnFields uint64, args ...interface{}) (int64, error)
+		UpdateReturningContext(ctx context.Context, model Model, fields, whereFields, returnFields uint64) (int64, error)
+		ArgsUpdateReturningContext(ctx context.Context, model Model, fields, whereFields, returnFields uint64, args ...interface{}) (int64, error)
+
+		Upsert(model Model, insertFields, conflictFields, updateFields uint64) (int64, error)
+		ArgsUpsert(model Model, insertFields, conflictFields, updateFields uint64, args ...interface{}) (int64, error)
+		UpsertContext(ctx context.Context, model Model, insertFields, conflictFields, updateFields uint64) (int64, error)
+		ArgsUpsertContext(ctx context.Context, model Model, insertFields, conflictFields, updateFields uint64, args ...interface{}) (int64, error)
+
+		BatchInsert(models []Model, fields uint64) (int64, []int64, error)
+		BatchInsertContext(ctx context.Context, models []Model, fields uint64) (int64, []int64, error)
+		BatchInsertReturning(models []Model, fields, returnFields uint64) (int64, error)
+		BatchInsertReturningContext(ctx context.Context, models []Model, fields, returnFields uint64) (int64, error)
 
 		Update(model Model, fields, whereFields uint64) (int64, error)
 		ArgsUpdate(model Model, fields, whereFields uint64, args ...interface{}) (int64, error)
+		UpdateContext(ctx context.Context, model Model, fields, whereFields uint64) (int64, error)
+		ArgsUpdateContext(ctx context.Context, model Model, fields, whereFields uint64, args ...interface{}) (int64, error)
 
 		Delete(model Model, whereFields uint64) (int64, error)
 		ArgsDelete(model Model, whereFields uint64, args ...interface{}) (int64, error)
+		DeleteContext(ctx context.Context, model Model, whereFields uint64) (int64, error)
+		ArgsDeleteContext(ctx context.Context, model Model, whereFields uint64, args ...interface{}) (int64, error)
+
+		HardDelete(model Model, whereFields uint64) (int64, error)
+		ArgsHardDelete(model Model, whereFields uint64, args ...interface{}) (int64, error)
+		HardDeleteContext(ctx context.Context, model Model, whereFields uint64) (int64, error)
+		ArgsHardDeleteContext(ctx context.Context, model Model, whereFields uint64, args ...interface{}) (int64, error)
 
 		One(model Model, fields, whereFields uint64) error
 		ArgsOne(model Model, fields, whereFields uint64, args []interface{}, ptrs ...interface{}) error
+		OneContext(ctx context.Context, model Model, fields, whereFields uint64) error
+		ArgsOneContext(ctx context.Context, model Model, fields, whereFields uint64, args []interface{}, ptrs ...interface{}) error
 
 		Limit(store Store, model Model, fields, whereFields uint64, start, count int64) error
 		ArgsLimit(store Store, model Model, fields, whereFields uint64, args ...interface{}) error
+		LimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, start, count int64) error
+		ArgsLimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, args ...interface{}) error
 
 		All(store Store, model Model, fields, whereFields uint64) error
 		ArgsAll(store Store, model Model, fields, whereFields uint64, args ...interface{}) error
+		AllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64) error
+		ArgsAllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, args ...interface{}) error
+
+		OrderOne(model Model, fields, whereFields uint64, order Order) error
+		ArgsOrderOne(model Model, fields, whereFields uint64, order Order, args []interface{}, ptrs ...interface{}) error
+		OrderOneContext(ctx context.Context, model Model, fields, whereFields uint64, order Order) error
+		ArgsOrderOneContext(ctx context.Context, model Model, fields, whereFields uint64, order Order, args []interface{}, ptrs ...interface{}) error
+
+		OrderLimit(store Store, model Model, fields, whereFields uint64, order Order, start, count int64) error
+		ArgsOrderLimit(store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error
+		OrderLimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, start, count int64) error
+		ArgsOrderLimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error
+
+		OrderAll(store Store, model Model, fields, whereFields uint64, order Order) error
+		ArgsOrderAll(store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error
+		OrderAllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order) error
+		ArgsOrderAllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error
+
+		Iter(model Model, fields, whereFields uint64, order Order) Scanner
+		ArgsIter(model Model, fields, whereFields uint64, order Order, args ...interface{}) Scanner
+		IterContext(ctx context.Context, model Model, fields, whereFields uint64, order Order) Scanner
+		ArgsIterContext(ctx context.Context, model Model, fields, whereFields uint64, order Order, args ...interface{}) Scanner
+
+		Page(store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int) (string, error)
+		ArgsPage(store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int, args ...interface{}) (string, error)
+		PageContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int) (string, error)
+		ArgsPageContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int, args ...interface{}) (string, error)
 
 		Count(model Model, whereFields uint64) (count int64, err error)
 		ArgsCount(model Model, whereFields uint64, args ...interface{}) (count int64, err error)
+		CountContext(ctx context.Context, model Model, whereFields uint64) (count int64, err error)
+		ArgsCountContext(ctx context.Context, model Model, whereFields uint64, args ...interface{}) (count int64, err error)
 
 		IncrBy(model Model, field, whereFields uint64, counts ...int) (int64, error)
 		ArgsIncrBy(model Model, field, whereFields uint64, args ...interface{}) (int64, error)
+		IncrByContext(ctx context.Context, model Model, field, whereFields uint64, counts ...int) (int64, error)
+		ArgsIncrByContext(ctx context.Context, model Model, field, whereFields uint64, args ...interface{}) (int64, error)
 
 		Exists(model Model, field, whereFields uint64) (bool, error)
 		ArgsExists(model Model, field, whereFields uint64, args ...interface{}) (bool, error)
+		ExistsContext(ctx context.Context, model Model, field, whereFields uint64) (bool, error)
+		ArgsExistsContext(ctx context.Context, model Model, field, whereFields uint64, args ...interface{}) (bool, error)
 
 		ExecUpdate(sql string, args ...interface{}) (int64, error)
 		Exec(sql string, resType ResultType, args ...interface{}) (int64, error)
+		ExecUpdateContext(ctx context.Context, sql string, args ...interface{}) (int64, error)
+		ExecContext(ctx context.Context, sql string, resType ResultType, args ...interface{}) (int64, error)
 
 		ExecById(sqlid uint64, resTyp ResultType, args ...interface{}) (int64, error)
 		UpdateById(sqlid uint64, args ...interface{}) (int64, error)
 		QueryById(sqlid uint64, args ...interface{}) Scanner
+		ExecByIdContext(ctx context.Context, sqlid uint64, resTyp ResultType, args ...interface{}) (int64, error)
+		UpdateByIdContext(ctx context.Context, sqlid uint64, args ...interface{}) (int64, error)
+		QueryByIdContext(ctx context.Context, sqlid uint64, args ...interface{}) Scanner
 	}
 
 	ResultType int
@@ -59,20 +136,38 @@ func Update(stmt Stmt, err error, args ...interface{}) (int64, error) {
 
 // Exec execute stmt with given arguments and resolve the result if error is nil
 func Exec(stmt Stmt, err error, typ ResultType, args ...interface{}) (int64, error) {
+	return ExecContext(context.Background(), stmt, err, typ, args...)
+}
+
+// Query execute the query stmt, error stored in Scanner
+func Query(stmt Stmt, err error, args ...interface{}) Scanner {
+	return QueryContext(context.Background(), stmt, err, args...)
+}
+
+// UpdateContext is similar to Update, but execute stmt with the context
+func UpdateContext(ctx context.Context, stmt Stmt, err error, args ...interface{}) (int64, error) {
+	return ExecContext(ctx, stmt, err, RES_ROWS, args...)
+}
+
+// ExecContext is similar to Exec, but execute stmt with the context, if context
+// is canceled or timeout, the context error was returned
+func ExecContext(ctx context.Context, stmt Stmt, err error, typ ResultType, args ...interface{}) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
 
-	res, err := stmt.Exec(args...)
+	res, err := stmt.ExecContext(ctx, args...)
 	return ResolveResult(res, err, typ)
 }
 
-// Query execute the query stmt, error stored in Scanner
-func Query(stmt Stmt, err error, args ...interface{}) Scanner {
+// QueryContext is similar to Query, but execute stmt with the context, the rows
+// will be closed when context is canceled, and the context error was stored in
+// Scanner
+func QueryContext(ctx context.Context, stmt Stmt, err error, args ...interface{}) Scanner {
 	if err != nil {
 		return Scanner{Error: err}
 	}
-	rows, err := stmt.Query(args...)
+	rows, err := stmt.QueryContext(ctx, args...)
 	return Scanner{
 		Error: err,
 		Rows:  rows,
@@ -87,11 +182,21 @@ func CloseUpdate(stmt Stmt, err error, args ...interface{}) (int64, error) {
 
 // Exec execute stmt with given arguments and resolve the result if error is nil
 func CloseExec(stmt Stmt, err error, typ ResultType, args ...interface{}) (int64, error) {
+	return CloseExecContext(context.Background(), stmt, err, typ, args...)
+}
+
+// CloseUpdateContext is similar to CloseUpdate, but execute stmt with the context
+func CloseUpdateContext(ctx context.Context, stmt Stmt, err error, args ...interface{}) (int64, error) {
+	return CloseExecContext(ctx, stmt, err, RES_ROWS, args...)
+}
+
+// CloseExecContext is similar to CloseExec, but execute stmt with the context
+func CloseExecContext(ctx context.Context, stmt Stmt, err error, typ ResultType, args ...interface{}) (int64, error) {
 	if err == nil {
 		defer stmt.Close()
 	}
 
-	return Exec(stmt, err, typ, args...)
+	return ExecContext(ctx, stmt, err, typ, args...)
 }
 
 // ResolveResult resolve sql result, if need id, return last insert id
@@ -0,0 +1,213 @@
+package gomodel
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// fakedb is a minimal database/sql driver for tests, it records executed sqls
+// and answer queries with the rows returned by the query function
+type fakedb struct {
+	mu       sync.Mutex
+	execs    []string
+	prepares int
+	closes   int
+	query    func(sql string, args []driver.Value) (cols []string, rows [][]driver.Value)
+	affected func(sql string) int64 // rows affected of exec, default 1
+	autoInc  bool                   // report mysql like LastInsertId for INSERT
+	lastId   int64
+}
+
+// errFakeRetry is treated as retryable error by fake driver
+var errFakeRetry = errors.New("fake retryable error")
+
+var fakedbs = struct {
+	sync.Mutex
+	dbs map[string]*fakedb
+}{dbs: make(map[string]*fakedb)}
+
+func init() {
+	sql.Register("gomodel_fake", fakeDriver{})
+}
+
+type fakeDriverName string
+
+func (d fakeDriverName) String() string { return string(d) }
+func (fakeDriverName) DSN(_, _, _, _, dbname string, _ map[string]string) string {
+	return dbname
+}
+func (fakeDriverName) Prepare(sql string) string               { return sql }
+func (fakeDriverName) SQLLimit() string                        { return "LIMIT ?, ?" }
+func (fakeDriverName) ParamLimit(offset, count int) (int, int) { return offset, count }
+func (fakeDriverName) MaxParams() int                          { return 8 }
+func (fakeDriverName) SQLUpsert(conflictCols, updateCols []string) string {
+	return "ON CONFLICT (" + strings.Join(conflictCols, ",") + ") DO UPDATE SET " + strings.Join(updateCols, ",")
+}
+func (fakeDriverName) SQLReturning(cols []string) string {
+	return "RETURNING " + strings.Join(cols, ",")
+}
+func (fakeDriverName) FirstInsertId(lastInsertId int64, _ int) int64 { return lastInsertId }
+func (fakeDriverName) Retryable(err error) bool                      { return err == errFakeRetry }
+func (fakeDriverName) PrimaryKey() string                            { return "PRIMARY" }
+func (fakeDriverName) DuplicateKey(err error) string                 { return "" }
+func (fakeDriverName) ForeignKey(err error) string                   { return "" }
+
+// openFake open a DB connected to a new fake database with given name
+func openFake(name string, opts ...DBOption) (*DB, *fakedb) {
+	fdb := &fakedb{}
+	fakedbs.Lock()
+	fakedbs.dbs[name] = fdb
+	fakedbs.Unlock()
+
+	db, err := Open(fakeDriverName("gomodel_fake"), name, 4, 4, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return db, fdb
+}
+
+func (f *fakedb) Execs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.execs...)
+}
+
+func (f *fakedb) record(sql string) {
+	f.mu.Lock()
+	f.execs = append(f.execs, sql)
+	f.mu.Unlock()
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakedbs.Lock()
+	fdb := fakedbs.dbs[name]
+	fakedbs.Unlock()
+
+	return &fakeConn{db: fdb}, nil
+}
+
+type fakeConn struct {
+	db *fakedb
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.db.mu.Lock()
+	c.db.prepares++
+	c.db.mu.Unlock()
+
+	return &fakeStmt{db: c.db, sql: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN")
+	return fakeTx{c.db}, nil
+}
+
+func (c *fakeConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.ReadOnly {
+		c.db.record("BEGIN READ ONLY")
+	} else {
+		c.db.record("BEGIN")
+	}
+	return fakeTx{c.db}, nil
+}
+
+type fakeTx struct {
+	db *fakedb
+}
+
+func (t fakeTx) Commit() error {
+	t.db.record("COMMIT")
+	return nil
+}
+
+func (t fakeTx) Rollback() error {
+	t.db.record("ROLLBACK")
+	return nil
+}
+
+type fakeStmt struct {
+	db  *fakedb
+	sql string
+}
+
+func (s *fakeStmt) Close() error {
+	s.db.mu.Lock()
+	s.db.closes++
+	s.db.mu.Unlock()
+
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.record(s.sql)
+	n := int64(1)
+	if s.db.affected != nil {
+		n = s.db.affected(s.sql)
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	if !s.db.autoInc || !strings.HasPrefix(s.sql, "INSERT") {
+		return driver.RowsAffected(n), nil
+	}
+	res := fakeResult{id: s.db.lastId + 1, n: n}
+	s.db.lastId += n
+	return res, nil
+}
+
+// fakeResult report the id of first inserted row as LastInsertId like mysql
+type fakeResult struct {
+	id, n int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.id, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.n, nil }
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.record(s.sql)
+
+	var (
+		cols []string
+		rows [][]driver.Value
+	)
+	s.db.mu.Lock()
+	query := s.db.query
+	s.db.mu.Unlock()
+	if query != nil {
+		cols, rows = query(s.sql, args)
+	}
+	if cols == nil && strings.HasPrefix(s.sql, "SELECT") {
+		cols = []string{"_"}
+	}
+	return &fakeRows{cols: cols, rows: rows}, nil
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,242 @@
+package gomodel
+
+import "context"
+
+type (
+	// Fieldset is a multiple words bitset of fields for models have more than 64
+	// fields which can't be represented by a uint64, the field at index i is
+	// the bit i%64 of word i/64, so the first word is same as the uint64 fields.
+	//
+	// Models have no more than 64 fields should just use uint64 fields, it's
+	// much more efficient.
+	Fieldset []uint64
+
+	// WideModel is a optional interface for Model, it's required for models have
+	// more than 64 fields, the uint64 fields of Model's Vals/Ptrs method should
+	// be treated as the first word of Fieldset.
+	//
+	// Wide* functions call the lifecycle hooks like Executor, but version,
+	// soft delete and auto timestamp fields are represented by uint64 and
+	// can't be used for wide models, DB.Table panics if a model has more than
+	// 64 fields use them.
+	WideModel interface {
+		Model
+		// WideVals is same as Vals, but for Fieldset
+		WideVals(fields Fieldset, vals []interface{})
+		// WidePtrs is same as Ptrs, but for Fieldset
+		WidePtrs(fields Fieldset, ptrs []interface{})
+	}
+)
+
+// FieldsetOf create a fieldset contains fields at given indexes
+func FieldsetOf(indexes ...int) Fieldset {
+	var f Fieldset
+	for _, index := range indexes {
+		f = f.grow(index/64 + 1)
+		f[index/64] |= 1 << uint(index%64)
+	}
+
+	return f
+}
+
+// FieldsetAll create a fieldset contains all fields of model
+func FieldsetAll(numField int) Fieldset {
+	f := make(Fieldset, (numField+63)/64)
+	for i := range f {
+		f[i] = ^uint64(0)
+	}
+	if r := numField % 64; r != 0 {
+		f[len(f)-1] = 1<<uint(r) - 1
+	}
+
+	return f
+}
+
+func (f Fieldset) grow(words int) Fieldset {
+	if len(f) >= words {
+		return f
+	}
+
+	n := make(Fieldset, words)
+	copy(n, f)
+	return n
+}
+
+// Has check whether field at index is in fieldset
+func (f Fieldset) Has(index int) bool {
+	w := index / 64
+
+	return w < len(f) && f[w]&(1<<uint(index%64)) != 0
+}
+
+// NumFields return count of fields in fieldset
+func (f Fieldset) NumFields() int {
+	var n int
+	for _, w := range f {
+		n += NumFields(w)
+	}
+
+	return n
+}
+
+// Empty check whether there is no fields in fieldset
+func (f Fieldset) Empty() bool {
+	for _, w := range f {
+		if w != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Union create a new fieldset contains fields of all fieldsets
+func (f Fieldset) Union(fs ...Fieldset) Fieldset {
+	n := append(Fieldset(nil), f...)
+	for _, f := range fs {
+		n = n.grow(len(f))
+		for i, w := range f {
+			n[i] |= w
+		}
+	}
+
+	return n
+}
+
+// Except create a new fieldset without fields at given indexes
+func (f Fieldset) Except(indexes ...int) Fieldset {
+	n := append(Fieldset(nil), f...)
+	for _, index := range indexes {
+		if w := index / 64; w < len(n) {
+			n[w] &^= 1 << uint(index%64)
+		}
+	}
+
+	return n
+}
+
+// key return a string can be used as map key, trailing zero words are ignored
+// so fieldsets contains same fields have the same key
+func (f Fieldset) key() string {
+	l := len(f)
+	for l > 0 && f[l-1] == 0 {
+		l--
+	}
+
+	buf := make([]byte, 0, l*8)
+	for _, w := range f[:l] {
+		for i := uint(0); i < 64; i += 8 {
+			buf = append(buf, byte(w>>i))
+		}
+	}
+
+	return string(buf)
+}
+
+// FieldsetVals is similar to FieldVals, but for Fieldset
+func FieldsetVals(model WideModel, fields Fieldset, args ...interface{}) []interface{} {
+	c, l := fields.NumFields(), len(args)
+	vals := make([]interface{}, c+l)
+	model.WideVals(fields, vals)
+	copy(vals[c:], args)
+
+	return vals
+}
+
+// FieldsetPtrs is similar to FieldPtrs, but for Fieldset
+func FieldsetPtrs(model WideModel, fields Fieldset, args ...interface{}) []interface{} {
+	c, l := fields.NumFields(), len(args)
+	ptrs := make([]interface{}, c+l)
+	model.WidePtrs(fields, ptrs)
+	copy(ptrs[c:], args)
+
+	return ptrs
+}
+
+// fieldsetStmt get cached statement for Fieldset, for Tx, the statement is
+// bound to transaction
+func fieldsetStmt(ctx context.Context, exec Executor, model Model, sqlType SQLType, fields, whereFields Fieldset) (Stmt, error) {
+	t := exec.Table(model)
+	if tx, is := exec.(*Tx); is {
+		stmt, err := t.fieldsetStmt(ctx, tx.db, sqlType, fields, whereFields)
+		return tx.bind(ctx, stmt, err)
+	}
+
+	return t.fieldsetStmt(ctx, exec, sqlType, fields, whereFields)
+}
+
+// WideInsert is similar to Executor.InsertContext, but for WideModel
+func WideInsert(ctx context.Context, exec Executor, model WideModel, fields Fieldset, resType ResultType) (int64, error) {
+	if err := beforeHook(ctx, exec, model, INSERT); err != nil {
+		return 0, err
+	}
+
+	stmt, err := fieldsetStmt(ctx, exec, model, INSERT, fields, nil)
+	n, err := CloseExecContext(ctx, stmt, err, resType, FieldsetVals(model, fields)...)
+	return afterHook(ctx, exec, model, INSERT, n, err)
+}
+
+// WideUpdate is similar to Executor.UpdateContext, but for WideModel
+func WideUpdate(ctx context.Context, exec Executor, model WideModel, fields, whereFields Fieldset) (int64, error) {
+	if err := beforeHook(ctx, exec, model, UPDATE); err != nil {
+		return 0, err
+	}
+
+	c1, c2 := fields.NumFields(), whereFields.NumFields()
+	args := make([]interface{}, c1+c2)
+	model.WideVals(fields, args)
+	model.WideVals(whereFields, args[c1:])
+
+	stmt, err := fieldsetStmt(ctx, exec, model, UPDATE, fields, whereFields)
+	n, err := CloseUpdateContext(ctx, stmt, err, args...)
+	return afterHook(ctx, exec, model, UPDATE, n, err)
+}
+
+// WideDelete is similar to Executor.DeleteContext, but for WideModel
+func WideDelete(ctx context.Context, exec Executor, model WideModel, whereFields Fieldset) (int64, error) {
+	if err := beforeHook(ctx, exec, model, DELETE); err != nil {
+		return 0, err
+	}
+
+	stmt, err := fieldsetStmt(ctx, exec, model, DELETE, nil, whereFields)
+	n, err := CloseUpdateContext(ctx, stmt, err, FieldsetVals(model, whereFields)...)
+	return afterHook(ctx, exec, model, DELETE, n, err)
+}
+
+// WideOne is similar to Executor.OneContext, but for WideModel
+func WideOne(ctx context.Context, exec Executor, model WideModel, fields, whereFields Fieldset) error {
+	stmt, err := fieldsetStmt(ctx, exec, model, ONE, fields, whereFields)
+	scanner := QueryContext(ctx, stmt, err, FieldsetVals(model, whereFields)...)
+	defer scanner.Close()
+
+	if err := scanner.One(FieldsetPtrs(model, fields)...); err != nil {
+		return err
+	}
+	return afterScan(model)
+}
+
+// WideAll is similar to Executor.AllContext, but for WideModel
+func WideAll(ctx context.Context, exec Executor, store Store, model WideModel, fields, whereFields Fieldset) error {
+	stmt, err := fieldsetStmt(ctx, exec, model, ALL, fields, whereFields)
+	scanner := QueryContext(ctx, stmt, err, FieldsetVals(model, whereFields)...)
+	defer scanner.Close()
+
+	var initsize int
+	switch exec := exec.(type) {
+	case *DB:
+		initsize = exec.InitialModels
+	case *Tx:
+		initsize = exec.db.InitialModels
+	}
+	return scanner.All(store, initsize)
+}
+
+// WideCount is similar to Executor.CountContext, but for WideModel
+func WideCount(ctx context.Context, exec Executor, model WideModel, whereFields Fieldset) (count int64, err error) {
+	stmt, err := fieldsetStmt(ctx, exec, model, COUNT, nil, whereFields)
+	scanner := QueryContext(ctx, stmt, err, FieldsetVals(model, whereFields)...)
+	defer scanner.Close()
+	err = scanner.One(&count)
+
+	return
+}
@@ -1,7 +1,14 @@
 package gomodel
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/cosiner/gohper/strings2"
 	"github.com/cosiner/gohper/testing2"
@@ -37,4 +44,1305 @@ func TestCols(t *testing.T) {
 func TestInterface(t *testing.T) {
 	var _ Executor = &DB{}
 	var _ Executor = &Tx{}
+	var _ Executor = &Shards{}
+	var _ Executor = &ShardTx{}
+}
+
+const (
+	testUserId uint64 = 1 << iota
+	testUserName
+	testUserAge
+
+	testUserFieldsAll = 1<<iota - 1
+)
+
+type testUser struct {
+	Id   int64
+	Name string
+	Age  int
+}
+
+func (u *testUser) Table() string {
+	return "user"
+}
+
+func (u *testUser) Columns() []string {
+	return []string{"id", "name", "age"}
+}
+
+func (u *testUser) Vals(fields uint64, vals []interface{}) {
+	index := 0
+	if fields&testUserId != 0 {
+		vals[index] = u.Id
+		index++
+	}
+	if fields&testUserName != 0 {
+		vals[index] = u.Name
+		index++
+	}
+	if fields&testUserAge != 0 {
+		vals[index] = u.Age
+		index++
+	}
+}
+
+func (u *testUser) Ptrs(fields uint64, ptrs []interface{}) {
+	index := 0
+	if fields&testUserId != 0 {
+		ptrs[index] = &u.Id
+		index++
+	}
+	if fields&testUserName != 0 {
+		ptrs[index] = &u.Name
+		index++
+	}
+	if fields&testUserAge != 0 {
+		ptrs[index] = &u.Age
+		index++
+	}
+}
+
+func TestContext(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, _ := openFake("context")
+
+	u := &testUser{Id: 1, Name: "abc", Age: 20}
+	_, err := db.Insert(u, testUserFieldsAll, RES_ROWS)
+	tt.Nil(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = db.InsertContext(ctx, u, testUserFieldsAll, RES_ROWS)
+	tt.Eq(context.Canceled, err)
+	tt.Eq(context.Canceled, db.OneContext(ctx, u, testUserName, testUserId))
+
+	_, err = db.BeginTx(ctx, nil)
+	tt.Eq(context.Canceled, err)
+
+	err = db.TxDo(func(tx *Tx) error {
+		_, err := tx.UpdateContext(ctx, u, testUserName, testUserId)
+		return err
+	})
+	tt.Eq(context.Canceled, err)
+
+	// transaction is rollbacked by database/sql if it's context is cancelled
+	for _, success := range []bool{true, false} {
+		ctx, cancel = context.WithCancel(context.Background())
+		tx, err := db.BeginTx(ctx, nil)
+		tt.Nil(err)
+		cancel()
+		tx.Success(success)
+		tt.Eq(context.Canceled, tx.Close())
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	err = db.TxDoContext(ctx, func(tx *Tx) error {
+		cancel()
+		return nil
+	})
+	tt.Eq(context.Canceled, err)
+}
+
+type testUserStore struct {
+	Values []testUser
+	Fields uint64
+}
+
+func (s *testUserStore) Init(size int) {
+	s.Values = make([]testUser, size)
+}
+
+func (s *testUserStore) Final(size int) {
+	s.Values = s.Values[:size]
+}
+
+func (s *testUserStore) Ptrs(index int, ptrs []interface{}) {
+	fields := s.Fields
+	if fields == 0 {
+		fields = testUserFieldsAll
+	}
+	s.Values[index].Ptrs(fields, ptrs)
+}
+
+func (s *testUserStore) Realloc(count int) int {
+	values := make([]testUser, 2*count)
+	copy(values, s.Values)
+	s.Values = values
+
+	return 2 * count
+}
+
+func TestFieldset(t *testing.T) {
+	tt := testing2.Wrap(t)
+
+	fs := FieldsetOf(0, 3, 64, 70)
+	tt.Eq(2, len(fs))
+	tt.Eq(4, fs.NumFields())
+	tt.True(fs.Has(64) && fs.Has(70) && !fs.Has(65) && !fs.Has(200))
+	tt.Eq(FieldsetOf(0, 3).key(), Fieldset{9, 0}.key())
+	tt.Eq(FieldsetOf(0, 64), fs.Except(3, 70))
+	tt.Eq(fs, FieldsetOf(0, 3).Union(FieldsetOf(64, 70)))
+	tt.Eq(Fieldset{1<<10 - 1}, FieldsetAll(10))
+	tt.Eq(Fieldset{^uint64(0), 1}, FieldsetAll(65))
+
+	cols := make([]string, 80)
+	for i := range cols {
+		cols[i] = fmt.Sprintf("c%d", i)
+	}
+	table := newTable("wide", cols, false)
+	tt.Eq("c0,c3,c64,c70", table.FieldsetCols(fs).String())
+	tt.Eq("UPDATE wide SET c64=? WHERE c0=?", table.FieldsetSQL(nil, UPDATE, FieldsetOf(64), FieldsetOf(0)))
+
+	// over 30 fields, the packed identity will conflict
+	tt.True(table.identity(UPDATE, 1<<31, 1) != table.identity(UPDATE, 1, 1<<31))
+	tt.Eq("UPDATE wide SET c40=? WHERE c31=?", table.SQLUpdate(nil, 1<<40, 1<<31))
+}
+
+func TestOrder(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("order")
+	table := db.Table(&testUser{})
+
+	order := Desc(testUserAge).Asc(testUserId)
+	tt.Eq("ORDER BY id ASC,age DESC", table.OrderBy(order))
+	tt.Eq("", table.OrderBy(Order{}))
+	tt.True(table.keyIdentity(stmtKey{sqlType: ALL, fields: testUserFieldsAll, order: order}) != table.keyIdentity(stmtKey{sqlType: ALL, fields: testUserFieldsAll, order: Asc(testUserAge)}))
+	tt.Eq(table.identity(ALL, testUserFieldsAll, 0), table.keyIdentity(stmtKey{sqlType: ALL, fields: testUserFieldsAll}))
+
+	var users testUserStore
+	u := &testUser{Name: "abc"}
+	tt.Eq(sql.ErrNoRows, db.OrderLimit(&users, u, testUserId, testUserName, order, 0, 10))
+	tt.Eq(sql.ErrNoRows, db.OrderAll(&users, u, testUserId, testUserName, Asc(testUserAge)))
+	tt.Eq(sql.ErrNoRows, db.OrderOne(u, testUserId, 0, Desc(testUserId)))
+	tt.DeepEq([]string{
+		"SELECT id FROM user WHERE name=? ORDER BY id ASC,age DESC LIMIT ?, ?",
+		"SELECT id FROM user WHERE name=? ORDER BY age ASC",
+		"SELECT id FROM user  ORDER BY id DESC LIMIT 1",
+	}, fdb.Execs())
+}
+
+func TestOps(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("ops")
+	table := db.Table(&testUser{})
+
+	ops := Where(testUserAge, GT).Set(testUserName, LIKE)
+	tt.Eq(GT, ops.Of(testUserAge))
+	tt.Eq(EQ, ops.Of(testUserId))
+	tt.Eq(EQ, ops.Set(testUserAge, EQ).Of(testUserAge))
+	tt.Eq("WHERE id=? AND name LIKE ? AND age>?", table.OpsWhere(testUserFieldsAll, ops))
+	tt.Eq(table.Where(testUserId), table.OpsWhere(testUserId, Ops{}))
+	tt.True(table.keyIdentity(stmtKey{sqlType: COUNT, whereFields: testUserAge, ops: ops}) !=
+		table.identity(COUNT, 0, testUserAge))
+
+	var args [][]driver.Value
+	fdb.query = func(_ string, a []driver.Value) ([]string, [][]driver.Value) {
+		args = append(args, a)
+		return []string{"count"}, [][]driver.Value{{int64(1)}}
+	}
+	u := &testUser{Id: 1, Name: "a%", Age: 10}
+	_, err := db.Count(WithOps(u, ops), testUserName|testUserAge)
+	tt.Nil(err)
+	_, err = db.Count(WithOps(u, Where(testUserName, ISNULL)), testUserName|testUserAge)
+	tt.Nil(err)
+	_, err = db.Update(WithOps(u, Where(testUserName, NOTNULL)), testUserAge, testUserId|testUserName)
+	tt.Nil(err)
+	tt.DeepEq([]string{
+		"SELECT COUNT(*) FROM user WHERE name LIKE ? AND age>?",
+		"SELECT COUNT(*) FROM user WHERE name IS NULL AND age=?",
+		"UPDATE user SET age=? WHERE id=? AND name IS NOT NULL",
+	}, fdb.Execs())
+	tt.DeepEq([][]driver.Value{{"a%", int64(10)}, {int64(10)}}, args)
+}
+
+func TestIn(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("in")
+
+	var args [][]driver.Value
+	fdb.query = func(_ string, a []driver.Value) ([]string, [][]driver.Value) {
+		args = append(args, a)
+		return nil, nil
+	}
+	var users testUserStore
+	u := WithOps(&testUser{}, In(testUserId))
+	tt.Eq(sql.ErrNoRows, db.ArgsAll(&users, u, testUserName, testUserId|testUserAge, []int64{1, 2, 3}, 10))
+	tt.Eq(sql.ErrNoRows, db.ArgsAll(&users, u, testUserName, testUserId|testUserAge, []int64{4, 5, 6, 7}, 10))
+	tt.Eq(sql.ErrNoRows, db.ArgsAll(&users, u, testUserName, testUserId|testUserAge, []int64{8}, 10))
+	_, err := db.ArgsUpdate(u, testUserName, testUserId, "abc", []int64{1, 2})
+	tt.Nil(err)
+	_, err = db.ArgsDelete(u, testUserId, []int64{})
+	tt.True(err != nil)
+
+	tt.Eq(3, fdb.prepares) // statements are cached per arity bucket
+	tt.DeepEq([]string{
+		"SELECT name FROM user WHERE id IN(?, ?, ?, ?) AND age=?",
+		"SELECT name FROM user WHERE id IN(?, ?, ?, ?) AND age=?",
+		"SELECT name FROM user WHERE id IN(?) AND age=?",
+		"UPDATE user SET name=? WHERE id IN(?, ?)",
+	}, fdb.Execs())
+	tt.DeepEq([][]driver.Value{
+		{int64(1), int64(2), int64(3), int64(3), int64(10)},
+		{int64(4), int64(5), int64(6), int64(7), int64(10)},
+		{int64(8), int64(10)},
+	}, args)
+}
+
+func TestBatchInsert(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("batch")
+
+	models := make([]Model, 5)
+	for i := range models {
+		models[i] = &testUser{Id: int64(i), Name: "abc", Age: i}
+	}
+	// fake driver allows 8 placeholders, 2 rows per chunk
+	n, ids, err := db.BatchInsert(models, testUserFieldsAll)
+	tt.Nil(err)
+	tt.Eq(int64(3), n) // fake driver always affect 1 row
+	tt.Eq(0, len(ids)) // LastInsertId is unsupported
+	tt.Eq(2, fdb.prepares)
+	tt.DeepEq([]string{
+		"INSERT INTO user(id,name,age) VALUES(?,?,?),(?,?,?)",
+		"INSERT INTO user(id,name,age) VALUES(?,?,?),(?,?,?)",
+		"INSERT INTO user(id,name,age) VALUES(?,?,?)",
+	}, fdb.Execs())
+
+	// ids of each model are resolved from the first id of each chunk
+	db, fdb = openFake("batch_ids")
+	fdb.autoInc = true
+	fdb.lastId = 10
+	fdb.affected = func(sql string) int64 {
+		return int64(strings.Count(sql, "(") - 1)
+	}
+	users := make([]Model, 5)
+	for i := range users {
+		users[i] = &testUser{Name: "abc", Age: i}
+	}
+	n, ids, err = db.BatchInsert(users, testUserName|testUserAge)
+	tt.Nil(err)
+	tt.Eq(int64(5), n)
+	tt.DeepEq([]int64{11, 12, 13, 14, 15}, ids)
+
+	// RETURNING clause is used if driver support it
+	db, fdb = openFake("batch_returning")
+	fdb.query = func(sql string, args []driver.Value) ([]string, [][]driver.Value) {
+		rows := make([][]driver.Value, len(args)/2)
+		for i := range rows {
+			rows[i] = []driver.Value{int64(20 + i)}
+		}
+		return []string{"id"}, rows
+	}
+	n, err = db.BatchInsertReturning(users, testUserName|testUserAge, testUserId)
+	tt.Nil(err)
+	tt.Eq(int64(5), n)
+	for i, u := range users {
+		tt.Eq(int64(20+i%4), u.(*testUser).Id)
+	}
+	tt.DeepEq([]string{
+		"INSERT INTO user(name,age) VALUES(?,?),(?,?),(?,?),(?,?) RETURNING id",
+		"INSERT INTO user(name,age) VALUES(?,?) RETURNING id",
+	}, fdb.Execs())
+}
+
+func TestUpsert(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("upsert")
+
+	u := &testUser{Id: 1, Name: "abc", Age: 10}
+	_, err := db.Upsert(u, testUserFieldsAll, testUserId, testUserName|testUserAge)
+	tt.Nil(err)
+	tt.DeepEq([]string{
+		"INSERT INTO user(id,name,age) VALUES(?,?,?) ON CONFLICT (id) DO UPDATE SET name,age",
+	}, fdb.Execs())
+}
+
+func TestReturning(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("returning")
+	fdb.query = func(string, []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"id"}, [][]driver.Value{{int64(9)}, {int64(10)}}
+	}
+
+	u := &testUser{Name: "abc", Age: 10}
+	tt.Nil(db.InsertReturning(u, testUserName|testUserAge, testUserId))
+	tt.Eq(int64(9), u.Id)
+
+	u.Id = 0
+	n, err := db.UpdateReturning(u, testUserAge, testUserName, testUserId)
+	tt.Nil(err)
+	tt.Eq(int64(2), n)
+	tt.Eq(int64(9), u.Id)
+	tt.DeepEq([]string{
+		"INSERT INTO user(name,age) VALUES(?,?) RETURNING id",
+		"UPDATE user SET age=? WHERE name=? RETURNING id",
+	}, fdb.Execs())
+
+	var id uint
+	tt.Nil(assignId(&id, 3))
+	tt.Eq(uint(3), id)
+	tt.True(assignId(new(string), 3) != nil)
+}
+
+func TestIter(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("iter")
+	fdb.query = func(string, []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"id"}, [][]driver.Value{{int64(1)}, {int64(2)}, {int64(3)}}
+	}
+
+	u := &testUser{}
+	scanner := db.Iter(u, testUserId, 0, Desc(testUserId))
+	var ids []int64
+	for scanner.Next() {
+		tt.Nil(scanner.Scan(u, testUserId))
+		ids = append(ids, u.Id)
+	}
+	tt.Nil(scanner.Err())
+	tt.DeepEq([]int64{1, 2, 3}, ids)
+
+	ids = ids[:0]
+	errStop := errors.New("stop")
+	err := db.Iter(u, testUserId, 0, Order{}).Each(u, testUserId, func() error {
+		ids = append(ids, u.Id)
+		if len(ids) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	tt.Eq(errStop, err)
+	tt.DeepEq([]int64{1, 2}, ids)
+}
+
+func TestPage(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("page")
+	var args [][]driver.Value
+	fdb.query = func(_ string, a []driver.Value) ([]string, [][]driver.Value) {
+		args = append(args, a)
+		return []string{"id", "name"}, [][]driver.Value{{int64(1), "a"}, {int64(2), "b"}}
+	}
+
+	users := testUserStore{Fields: testUserId | testUserName}
+	u := &testUser{Age: 10}
+	cursor, err := db.Page(&users, u, testUserId|testUserName, testUserAge, Asc(testUserId), "", 2)
+	tt.Nil(err)
+	tt.True(cursor != "")
+	_, err = db.Page(&users, u, testUserId|testUserName, testUserAge, Asc(testUserId), cursor, 3)
+	tt.Nil(err)
+	tt.Eq(int64(2), u.Id)
+	cursor, err = db.Page(&users, u, testUserId|testUserName, 0, Desc(testUserId), cursor, 3)
+	tt.Nil(err)
+	tt.Eq("", cursor)
+
+	_, err = db.Page(&users, u, testUserName, 0, Asc(testUserId), "", 2)
+	tt.Eq(ErrBadOrder, err)
+	_, err = db.Page(&users, u, testUserId, 0, Asc(testUserId), "bad cursor", 2)
+	tt.Eq(ErrBadCursor, err)
+
+	tt.DeepEq([]string{
+		"SELECT id,name FROM user WHERE age=? ORDER BY id ASC LIMIT ?",
+		"SELECT id,name FROM user WHERE age=? AND (id) > (?) ORDER BY id ASC LIMIT ?",
+		"SELECT id,name FROM user WHERE (id) < (?) ORDER BY id DESC LIMIT ?",
+	}, fdb.Execs())
+	tt.DeepEq([][]driver.Value{{int64(10), int64(2)}, {int64(10), int64(2), int64(3)}, {int64(2), int64(3)}}, args)
+
+	// the previous page has exactly count rows, the next page is empty
+	db, fdb = openFake("page_exact")
+	fdb.query = func(_ string, a []driver.Value) ([]string, [][]driver.Value) {
+		if len(a) == 2 {
+			return []string{"id", "name"}, [][]driver.Value{{int64(1), "a"}, {int64(2), "b"}}
+		}
+		return []string{"id", "name"}, nil
+	}
+	users = testUserStore{Fields: testUserId | testUserName}
+	cursor, err = db.Page(&users, u, testUserId|testUserName, testUserAge, Asc(testUserId), "", 2)
+	tt.Nil(err)
+	tt.Eq(2, len(users.Values))
+	cursor, err = db.Page(&users, u, testUserId|testUserName, testUserAge, Asc(testUserId), cursor, 2)
+	tt.Nil(err)
+	tt.Eq("", cursor)
+	tt.Eq(0, len(users.Values))
+}
+
+func TestSavepoint(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("savepoint")
+
+	u := &testUser{Id: 1, Name: "abc"}
+	errInner := errors.New("inner")
+	err := db.TxDo(func(tx *Tx) error {
+		tt.Eq(errInner, tx.TxDo(func(tx *Tx) error {
+			tt.Nil(tx.TxDo(func(tx *Tx) error {
+				_, err := tx.Update(u, testUserName, testUserId)
+				return err
+			}))
+			return errInner
+		}))
+		_, err := tx.Delete(u, testUserId)
+		return err
+	})
+	tt.Nil(err)
+	tt.DeepEq([]string{
+		"BEGIN",
+		"SAVEPOINT gomodel_sp1",
+		"SAVEPOINT gomodel_sp2",
+		"UPDATE user SET name=? WHERE id=?",
+		"RELEASE SAVEPOINT gomodel_sp2",
+		"ROLLBACK TO SAVEPOINT gomodel_sp1",
+		"RELEASE SAVEPOINT gomodel_sp1",
+		"DELETE FROM user WHERE id=?",
+		"COMMIT",
+	}, fdb.Execs())
+
+	// panic and Success(false) only rollback the nested transaction
+	db, fdb = openFake("savepoint_panic")
+	hook := &testHook{}
+	db.Hook = hook
+	err = db.TxDo(func(tx *Tx) error {
+		func() {
+			defer func() {
+				tt.Eq("nested panic", recover())
+			}()
+			tx.TxDo(func(tx *Tx) error {
+				panic("nested panic")
+			})
+		}()
+		tt.Eq(0, tx.nested)
+		tt.Nil(tx.TxDo(func(tx *Tx) error {
+			tx.Success(false)
+			return nil
+		}))
+		tt.Eq(ErrInvalidSavepoint, tx.Savepoint("sp; DROP TABLE user"))
+		tt.Eq(ErrInvalidSavepoint, tx.Savepoint("1sp"))
+		return nil
+	})
+	tt.Nil(err)
+	tt.DeepEq([]string{
+		"BEGIN",
+		"SAVEPOINT gomodel_sp1",
+		"ROLLBACK TO SAVEPOINT gomodel_sp1",
+		"RELEASE SAVEPOINT gomodel_sp1",
+		"SAVEPOINT gomodel_sp1",
+		"ROLLBACK TO SAVEPOINT gomodel_sp1",
+		"RELEASE SAVEPOINT gomodel_sp1",
+		"COMMIT",
+	}, fdb.Execs())
+	// savepoint statements are observed by hook
+	tt.Eq(6, len(hook.events))
+	tt.Eq("SAVEPOINT gomodel_sp1", hook.events[0].SQL)
+}
+
+func TestRetryTxDo(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, _ := openFake("retry")
+
+	var n int
+	err := db.RetryTxDo(Retry{Attempts: 3, Backoff: time.Millisecond}, func(tx *Tx) error {
+		n++
+		return errFakeRetry
+	})
+	tt.Eq(errFakeRetry, err)
+	tt.Eq(3, n)
+
+	n = 0
+	errOther := errors.New("other")
+	err = db.RetryTxDo(DefaultRetry, func(tx *Tx) error {
+		n++
+		if n == 1 {
+			return errFakeRetry
+		}
+		return errOther
+	})
+	tt.Eq(errOther, err)
+	tt.Eq(2, n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err = db.RetryTxDoContext(ctx, DefaultRetry, func(tx *Tx) error {
+		cancel()
+		return nil
+	})
+	tt.Eq(context.Canceled, err)
+
+	// the transaction is rollbacked if the function panics
+	db, fdb := openFake("retry_panic")
+	func() {
+		defer func() {
+			tt.Eq("retry panic", recover())
+		}()
+		db.RetryTxDo(DefaultRetry, func(tx *Tx) error {
+			panic("retry panic")
+		})
+	}()
+	tt.DeepEq([]string{"BEGIN", "ROLLBACK"}, fdb.Execs())
+	tt.Eq(0, db.Stats().InUse)
+}
+
+func TestTxOptions(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("txoptions")
+
+	readOnly := TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}
+	err := db.TxDoWith(readOnly, func(tx *Tx) error {
+		tt.Eq(readOnly, tx.Options())
+		tt.True(tx.Compatible(TxOptions{ReadOnly: true}))
+		tt.True(tx.Compatible(TxOptions{Isolation: sql.LevelReadCommitted, ReadOnly: true}))
+		tt.True(!tx.Compatible(TxOptions{}))
+		return nil
+	})
+	tt.Nil(err)
+
+	tx, err := db.BeginWith(TxOptions{Isolation: sql.LevelReadCommitted})
+	tt.Nil(err)
+	tt.True(tx.Compatible(TxOptions{ReadOnly: true}))
+	tt.True(!tx.Compatible(TxOptions{Isolation: sql.LevelSerializable}))
+	tt.Nil(tx.Close())
+
+	tt.DeepEq([]string{"BEGIN READ ONLY", "COMMIT", "BEGIN", "COMMIT"}, fdb.Execs())
+}
+
+func TestTxStmt(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("txstmt")
+
+	u := &testUser{Id: 1, Name: "abc"}
+	for i := 0; i < 3; i++ {
+		tt.Nil(db.TxDo(func(tx *Tx) error {
+			for j := 0; j < 3; j++ {
+				if _, err := tx.Update(u, testUserName, testUserId); err != nil {
+					return err
+				}
+			}
+			tt.Eq(1, len(tx.stmts))
+			return nil
+		}))
+	}
+	_, err := db.Update(u, testUserName, testUserId)
+	tt.Nil(err)
+	// prepared once for DB and once more on the connection of first transaction,
+	// then it's reused by later transactions
+	tt.Eq(2, fdb.prepares)
+}
+
+func TestStmtCache(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("stmtcache", WithStmtCacheSize(2))
+
+	u := &testUser{Id: 1, Name: "abc"}
+	update := func() {
+		_, err := db.Update(u, testUserName, testUserId)
+		tt.Nil(err)
+	}
+	update()
+	update()
+	_, err := db.Delete(u, testUserId)
+	tt.Nil(err)
+	_, err = db.Insert(u, testUserId|testUserName, RES_NO)
+	tt.Nil(err)
+	// all items are accessed after stored, the oldest one update is evicted and
+	// closed
+	tt.Eq(CacheStats{Size: 2, Hits: 1, Misses: 3, Evictions: 1}, db.CacheStats())
+	tt.Eq(1, fdb.closes)
+
+	update()
+	tt.Eq(CacheStats{Size: 2, Hits: 1, Misses: 4, Evictions: 2}, db.CacheStats())
+	tt.Eq(4, fdb.prepares)
+
+	tt.Nil(db.Close())
+	tt.Eq(0, db.CacheStats().Size)
+	tt.Eq(4, fdb.closes)
+
+	// tables created before Use share the cache of DB
+	db = NewDB(WithStmtCacheSize(2))
+	tt.True(db.Table(&testUser{}).cache == db.cache)
+	tt.Eq(2, db.cache.capacity)
+}
+
+func TestSharedStmt(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("sharedstmt", WithStmtCacheSize(1))
+
+	u := &testUser{Id: 1, Name: "abc"}
+	table := db.Table(u)
+	// statements returned to users don't hold the cached statement, it's
+	// closed immediately after evicted even if they are not closed
+	stmt, err := table.StmtUpdate(db, testUserName, testUserId)
+	tt.Nil(err)
+	_, err = stmt.Exec("abc", 1)
+	tt.Nil(err)
+	_, err = db.Delete(u, testUserId)
+	tt.Nil(err)
+	tt.Eq(1, fdb.closes)
+
+	// evicted statement is prepared and cached again
+	_, err = stmt.Exec("abc", 1)
+	tt.Nil(err)
+	tt.Eq(3, fdb.prepares)
+	tt.Eq(2, fdb.closes)
+	tt.Eq(1, db.CacheStats().Size)
+	_, err = stmt.Exec("abc", 1)
+	tt.Nil(err)
+	tt.Eq(3, fdb.prepares)
+	tt.Nil(stmt.Close())
+
+	tt.Nil(db.Close())
+	tt.Eq(3, fdb.closes)
+}
+
+func BenchmarkCacheHitParallel(b *testing.B) {
+	c := newCache(DefaultStmtCacheSize)
+	item := c.set(2, "SELECT 2", new(sql.Stmt))
+	item.release()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, item := c.acquire(2)
+			item.release()
+		}
+	})
+}
+
+type testHook struct {
+	events []QueryEvent
+}
+
+func (h *testHook) BeforeQuery(ctx context.Context, e *QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *testHook) AfterQuery(ctx context.Context, e *QueryEvent) {
+	h.events = append(h.events, *e)
+}
+
+func TestQueryHook(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, _ := openFake("hook")
+	hook := &testHook{}
+	db.Hook = hook
+
+	u := &testUser{Id: 1, Name: "abc"}
+	for i := 0; i < 2; i++ {
+		_, err := db.Update(u, testUserName, testUserId)
+		tt.Nil(err)
+	}
+	tt.Nil(db.TxDo(func(tx *Tx) error {
+		_, err := tx.Update(u, testUserName, testUserId)
+		return err
+	}))
+	_, err := db.ExecUpdate("DELETE FROM user")
+	tt.Nil(err)
+
+	tt.Eq(4, len(hook.events))
+	for i, cached := range []bool{false, true, true, false} {
+		e := hook.events[i]
+		tt.Eq(cached, e.Cached)
+		tt.Nil(e.Err)
+		tt.Eq(int64(1), e.Rows)
+	}
+	tt.Eq("UPDATE user SET name=? WHERE id=?", hook.events[0].SQL)
+	tt.DeepEq([]interface{}{"abc", int64(1)}, hook.events[0].Args)
+	tt.Eq("DELETE FROM user", hook.events[3].SQL)
+
+	// statements created by Prepare use the cached sql
+	table := db.Table(u)
+	for i := 0; i < 2; i++ {
+		stmt, err := table.PrepareUpdate(db, testUserAge, testUserId)
+		tt.Nil(err)
+		_, err = stmt.Exec(10, 1)
+		tt.Nil(err)
+		tt.Nil(stmt.Close())
+		tt.Eq(i == 1, hook.events[4+i].Cached)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, _ := openFake("metrics")
+	m := NewMemMetrics()
+	db.Metrics = m
+
+	u := &testUser{Id: 1, Name: "abc"}
+	for i := 0; i < 2; i++ {
+		_, err := db.Update(u, testUserName, testUserId)
+		tt.Nil(err)
+	}
+	_, err := db.ExecUpdate("DELETE FROM user")
+	tt.Nil(err)
+
+	stmts := m.Stmts()
+	tt.Eq(2, len(stmts))
+	update := stmts[MetricsKey{Table: "user", Type: UPDATE}]
+	tt.Eq(uint64(2), update.Count)
+	tt.Eq(uint64(0), update.Errors)
+	tt.Eq(len(DefaultLatencyBounds)+1, len(update.Buckets))
+	tt.Eq(uint64(1), stmts[MetricsKey{Type: RAW}].Count)
+	tt.Eq(uint64(2), m.ByType()[UPDATE].Count)
+	tt.Eq(uint64(2), m.ByTable()["user"].Count)
+	tt.Eq("UPDATE", UPDATE.String())
+
+	s := db.Snapshot()
+	tt.Eq(CacheStats{Size: 1, Hits: 1, Misses: 1}, s.Cache)
+	tt.True(s.Pool.OpenConnections > 0)
+}
+
+type testSpanKey struct{}
+
+type testSpan struct {
+	info   SpanInfo
+	parent *testSpan
+	ended  bool
+}
+
+func (s *testSpan) End(err error) {
+	s.ended = true
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, info SpanInfo) (context.Context, Span) {
+	parent, _ := ctx.Value(testSpanKey{}).(*testSpan)
+	s := &testSpan{info: info, parent: parent}
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, testSpanKey{}, s), s
+}
+
+func (t *testTracer) Inherit(ctx, parent context.Context) context.Context {
+	return context.WithValue(ctx, testSpanKey{}, parent.Value(testSpanKey{}))
+}
+
+func TestTracer(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, _ := openFake("tracer")
+	tracer := &testTracer{}
+	db.Tracer = tracer
+
+	u := &testUser{Id: 1, Name: "abc"}
+	_, err := db.Update(u, testUserName, testUserId)
+	tt.Nil(err)
+	tt.Nil(db.TxDo(func(tx *Tx) error {
+		_, err := tx.UpdateContext(context.Background(), u, testUserName, testUserId)
+		return err
+	}))
+	id := NewSqlId(func(Executor) string { return "DELETE FROM user" })
+	_, err = db.UpdateById(id)
+	tt.Nil(err)
+
+	tt.Eq(4, len(tracer.spans))
+	update, tx, txUpdate, byId := tracer.spans[0], tracer.spans[1], tracer.spans[2], tracer.spans[3]
+	tt.Eq(SpanInfo{
+		Name:  "gomodel.UPDATE",
+		Table: "user",
+		Type:  UPDATE,
+		SQL:   "UPDATE user SET name=? WHERE id=?",
+	}, update.info)
+	tt.Eq(SpanInfo{Name: SPAN_TX, Tx: true}, tx.info)
+	tt.True(txUpdate.parent == tx)
+	tt.Eq(SpanInfo{Name: "gomodel.RAW", Type: RAW, SQLId: id, ById: true, SQL: "DELETE FROM user"}, byId.info)
+	for _, s := range tracer.spans {
+		tt.True(s.ended)
+	}
+}
+
+func TestReplica(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, primary := openFake("replica_primary")
+	r1, fdb1 := openFake("replica_1")
+	r2, fdb2 := openFake("replica_2")
+	db.AddReplica(r1, 1)
+	db.AddReplica(r2, 2)
+	for _, fdb := range []*fakedb{primary, fdb1, fdb2} {
+		fdb.query = func(string, []driver.Value) ([]string, [][]driver.Value) {
+			return []string{"count"}, [][]driver.Value{{int64(1)}}
+		}
+	}
+
+	u := &testUser{Id: 1}
+	for i := 0; i < 6; i++ {
+		_, err := db.Count(u, testUserId)
+		tt.Nil(err)
+	}
+	_, err := db.Update(u, testUserName, testUserId)
+	tt.Nil(err)
+	tt.Nil(db.TxDo(func(tx *Tx) error {
+		_, err := tx.Count(u, testUserId)
+		return err
+	}))
+
+	tt.Eq(2, len(fdb1.Execs()))
+	tt.Eq(4, len(fdb2.Execs()))
+	tt.Eq(uint64(1), r1.CacheStats().Misses)
+	tt.DeepEq([]string{
+		"UPDATE user SET name=? WHERE id=?",
+		"BEGIN",
+		"SELECT COUNT(*) FROM user WHERE id=?",
+		"COMMIT",
+	}, primary.Execs())
+
+	// unhealthy replicas are skipped
+	list := db.replicas.list.Load().([]*replica)
+	list[1].healthy = 0
+	for i := 0; i < 3; i++ {
+		_, err := db.Count(u, testUserId)
+		tt.Nil(err)
+	}
+	tt.Eq(5, len(fdb1.Execs()))
+	list[0].healthy = 0
+	_, err = db.Count(u, testUserId)
+	tt.Nil(err)
+	tt.Eq(5, len(primary.Execs()))
+
+	tt.Nil(db.Close())
+}
+
+func TestShards(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db0, fdb0 := openFake("shard_0")
+	db1, fdb1 := openFake("shard_1")
+	_, err := NewShards(nil)
+	tt.Eq(ErrNoShards, err)
+
+	shards, err := NewShards(func(model Model, fields uint64, args []interface{}) (int, error) {
+		if model == nil {
+			return 0, ErrNoShard
+		}
+		if args == nil {
+			args = FieldVals(model, fields)
+		}
+		return int(args[0].(int64) % 2), nil
+	}, db0, db1)
+	tt.Nil(err)
+
+	_, err = shards.Update(&testUser{Id: 1, Name: "abc"}, testUserName, testUserId)
+	tt.Nil(err)
+	_, err = shards.ArgsUpdate(&testUser{}, testUserName, testUserId, "abc", int64(2))
+	tt.Nil(err)
+	_, err = shards.ArgsDelete(&testUser{}, testUserId, int64(4))
+	tt.Nil(err)
+	_, err = shards.ExecUpdate("DELETE FROM user")
+	tt.Eq(ErrNoShard, err)
+	_, err = shards.ExecUpdateContext(WithShard(context.Background(), 1), "DELETE FROM user")
+	tt.Nil(err)
+	tt.DeepEq([]string{
+		"UPDATE user SET name=? WHERE id=?",
+		"DELETE FROM user WHERE id=?",
+	}, fdb0.Execs())
+	tt.DeepEq([]string{
+		"UPDATE user SET name=? WHERE id=?",
+		"DELETE FROM user",
+	}, fdb1.Execs())
+
+	err = shards.TxDo(func(tx *ShardTx) error {
+		if _, err := tx.Delete(&testUser{Id: 3}, testUserId); err != nil {
+			return err
+		}
+		_, err := tx.Delete(&testUser{Id: 2}, testUserId)
+		return err
+	})
+	tt.Eq(ErrCrossShard, err)
+	tt.DeepEq([]string{"BEGIN", "DELETE FROM user WHERE id=?", "ROLLBACK"}, fdb1.Execs()[2:])
+
+	fdb0.autoInc, fdb1.autoInc = true, true
+	fdb1.affected = func(sql string) int64 {
+		return int64(strings.Count(sql, "(") - 1)
+	}
+	n, ids, err := shards.BatchInsert([]Model{&testUser{Id: 1}, &testUser{Id: 2}, &testUser{Id: 3}}, testUserId)
+	tt.Nil(err)
+	tt.Eq(int64(3), n)
+	tt.Eq("INSERT INTO user(id) VALUES(?),(?)", fdb1.Execs()[5])
+	tt.DeepEq([]int64{1, 1, 2}, ids) // ids are generated by each shard
+
+	// soft delete, version and page helpers are routed as other operations
+	_, err = shards.Delete(&testSoftUser{testUser{Id: 1}}, testUserId)
+	tt.Nil(err)
+	_, err = shards.HardDelete(&testSoftUser{testUser{Id: 3}}, testUserId)
+	tt.Nil(err)
+	_, err = shards.Update(&testVersionUser{testUser{Id: 5, Name: "abc", Age: 1}}, testUserName, testUserId)
+	tt.Nil(err)
+	_, err = shards.ArgsPage(&testUserStore{}, &testUser{}, testUserFieldsAll, testUserAge, Asc(testUserId), "", 10, int64(7))
+	tt.Nil(err)
+	tt.DeepEq([]string{
+		"UPDATE soft_user SET age=? WHERE id=? AND age IS NULL",
+		"DELETE FROM soft_user WHERE id=?",
+		"UPDATE version_user SET name=?,age=age+1 WHERE id=? AND age=?",
+		"SELECT id,name,age FROM user WHERE age=? ORDER BY id ASC LIMIT ?",
+	}, fdb1.Execs()[6:])
+	tt.Eq(3, len(fdb0.Execs()))
+}
+
+type testHookUser struct {
+	testUser
+	hooks []string
+}
+
+var errTestHook = errors.New("invalid name")
+
+func (u *testHookUser) BeforeInsert(ctx context.Context, exec Executor) error {
+	u.hooks = append(u.hooks, "BeforeInsert")
+	u.Name = "created"
+	return nil
+}
+
+func (u *testHookUser) AfterInsert(ctx context.Context, exec Executor) error {
+	u.hooks = append(u.hooks, "AfterInsert")
+	return nil
+}
+
+func (u *testHookUser) BeforeUpdate(ctx context.Context, exec Executor) error {
+	u.hooks = append(u.hooks, "BeforeUpdate")
+	if u.Name == "" {
+		return errTestHook
+	}
+	return nil
+}
+
+func (u *testHookUser) AfterDelete(ctx context.Context, exec Executor) error {
+	u.hooks = append(u.hooks, "AfterDelete")
+	return nil
+}
+
+func (u *testHookUser) AfterScan() error {
+	u.hooks = append(u.hooks, "AfterScan")
+	return nil
+}
+
+type testHookUserStore struct {
+	testUserStore
+	models []*testHookUser
+}
+
+func (s *testHookUserStore) Model(index int) Model {
+	u := &testHookUser{testUser: s.Values[index]}
+	s.models = append(s.models, u)
+	return u
+}
+
+func TestLifecycle(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("lifecycle")
+	fdb.query = func(string, []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"id", "name"}, [][]driver.Value{{int64(1), "abc"}}
+	}
+
+	u := &testHookUser{testUser: testUser{Id: 1}}
+	_, err := db.Insert(u, testUserId|testUserName, RES_NO)
+	tt.Nil(err)
+	tt.Eq("created", u.Name)
+	tt.Nil(db.One(u, testUserId|testUserName, testUserId))
+	_, err = db.Delete(u, testUserId)
+	tt.Nil(err)
+	tt.DeepEq([]string{"BeforeInsert", "AfterInsert", "AfterScan", "AfterDelete"}, u.hooks)
+
+	// hook error abort the operation and rollback the transaction
+	err = db.TxDo(func(tx *Tx) error {
+		if _, err := tx.Delete(u, testUserId); err != nil {
+			return err
+		}
+		_, err := tx.Update(&testHookUser{}, testUserName, testUserId)
+		return err
+	})
+	tt.Eq(errTestHook, err)
+	tt.Eq("ROLLBACK", fdb.Execs()[len(fdb.Execs())-1])
+
+	store := &testHookUserStore{testUserStore: testUserStore{Fields: testUserId | testUserName}}
+	tt.Nil(db.All(store, &testUser{}, testUserId|testUserName, 0))
+	tt.Eq(1, len(store.models))
+	tt.DeepEq([]string{"AfterScan"}, store.models[0].hooks)
+
+	// hooks of returning, upsert and batch insert
+	db, fdb = openFake("lifecycle_returning")
+	fdb.query = func(string, []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"id"}, [][]driver.Value{{int64(7)}}
+	}
+	u = &testHookUser{}
+	tt.Nil(db.InsertReturning(u, testUserName, testUserId))
+	tt.Eq(int64(7), u.Id)
+	tt.Eq("created", u.Name)
+	_, err = db.UpdateReturning(u, testUserName, testUserId, testUserId)
+	tt.Nil(err)
+	tt.DeepEq([]string{"BeforeInsert", "AfterInsert", "BeforeUpdate"}, u.hooks)
+	_, err = db.UpdateReturning(&testHookUser{}, testUserName, testUserId, testUserId)
+	tt.Eq(errTestHook, err)
+
+	u = &testHookUser{}
+	_, err = db.Upsert(u, testUserId|testUserName, testUserId, testUserName)
+	tt.Nil(err)
+	tt.DeepEq([]string{"BeforeInsert", "AfterInsert"}, u.hooks)
+
+	users := []*testHookUser{{}, {}}
+	_, _, err = db.BatchInsert([]Model{users[0], users[1]}, testUserName)
+	tt.Nil(err)
+	for _, u := range users {
+		tt.Eq("created", u.Name)
+		tt.DeepEq([]string{"BeforeInsert", "AfterInsert"}, u.hooks)
+	}
+	tt.Eq(4, len(fdb.Execs())) // the update aborted by hook is not executed
+}
+
+// testWideHookUser use the first word of Fieldset as uint64 fields
+type testWideHookUser struct {
+	testHookUser
+}
+
+func (u *testWideHookUser) WideVals(fields Fieldset, vals []interface{}) {
+	u.Vals(fields[0], vals)
+}
+
+func (u *testWideHookUser) WidePtrs(fields Fieldset, ptrs []interface{}) {
+	u.Ptrs(fields[0], ptrs)
+}
+
+func TestWideLifecycle(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("wide_lifecycle")
+	ctx := context.Background()
+
+	u := &testWideHookUser{testHookUser{testUser: testUser{Id: 1}}}
+	_, err := WideInsert(ctx, db, u, Fieldset{testUserId | testUserName}, RES_NO)
+	tt.Nil(err)
+	tt.Eq("created", u.Name)
+	_, err = WideDelete(ctx, db, u, Fieldset{testUserId})
+	tt.Nil(err)
+	_, err = WideUpdate(ctx, db, &testWideHookUser{}, Fieldset{testUserName}, Fieldset{testUserId})
+	tt.Eq(errTestHook, err)
+	tt.DeepEq([]string{"BeforeInsert", "AfterInsert", "AfterDelete"}, u.hooks)
+	tt.Eq(2, len(fdb.Execs()))
+}
+
+type testVersionUser struct {
+	testUser
+}
+
+func (u *testVersionUser) Table() string {
+	return "version_user"
+}
+
+func (u *testVersionUser) VersionField() uint64 {
+	return testUserAge
+}
+
+// testWideTagModel has 65 fields, the version tag is not supported
+type testWideTagModel struct {
+	F0, F1, F2, F3, F4, F5, F6, F7, F8, F9, F10, F11, F12, F13, F14, F15 int
+	F16, F17, F18, F19, F20, F21, F22, F23, F24, F25, F26, F27, F28, F29 int
+	F30, F31, F32, F33, F34, F35, F36, F37, F38, F39, F40, F41, F42, F43 int
+	F44, F45, F46, F47, F48, F49, F50, F51, F52, F53, F54, F55, F56, F57 int
+	F58, F59, F60, F61, F62, F63                                         int
+	Version                                                              int `version:"true"`
+}
+
+func (*testWideTagModel) Table() string                          { return "wide_tag_model" }
+func (*testWideTagModel) Vals(fields uint64, vals []interface{}) {}
+func (*testWideTagModel) Ptrs(fields uint64, ptrs []interface{}) {}
+
+// testWideColumnerModel has 65 columns and a version field
+type testWideColumnerModel struct {
+	testWideTagModel
+}
+
+func (*testWideColumnerModel) Table() string        { return "wide_columner_model" }
+func (*testWideColumnerModel) VersionField() uint64 { return 1 }
+func (*testWideColumnerModel) Columns() []string {
+	cols := make([]string, 65)
+	for i := range cols {
+		cols[i] = fmt.Sprintf("f%d", i)
+	}
+	return cols
+}
+
+type testTagModel struct {
+	Id        int64
+	Version   int        `version:"true"`
+	DeletedAt *time.Time `softdelete:"true"`
+	UpdatedAt time.Time  `autoupdate:"true"`
+}
+
+func (*testTagModel) Table() string                          { return "tag_model" }
+func (*testTagModel) Vals(fields uint64, vals []interface{}) {}
+func (*testTagModel) Ptrs(fields uint64, ptrs []interface{}) {}
+
+func TestVersion(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("version")
+	tt.Eq(uint64(2), db.Table(&testTagModel{}).version)
+	_, err := parseModel(&testWideTagModel{}, db)
+	tt.True(err != nil)
+	_, err = parseModel(&testWideColumnerModel{}, db)
+	tt.True(err != nil)
+	func() {
+		defer func() {
+			tt.True(recover() != nil)
+		}()
+		db.Table(&testWideColumnerModel{})
+	}()
+
+	u := &testVersionUser{testUser{Id: 1, Name: "abc", Age: 3}}
+	n, err := db.Update(u, testUserName|testUserAge, testUserId|testUserAge)
+	tt.Nil(err)
+	tt.Eq(int64(1), n)
+	tt.Eq(4, u.Age)
+	tt.Eq("UPDATE version_user SET name=?,age=age+1 WHERE id=? AND age=?", fdb.Execs()[len(fdb.Execs())-1])
+
+	fdb.affected = func(string) int64 { return 0 }
+	_, err = db.Update(u, testUserName, testUserId)
+	tt.Eq(ErrVersionConflict, err)
+	tt.Eq(4, u.Age)
+
+	_, err = db.ArgsUpdate(u, 0, testUserId, 1, 4)
+	tt.Eq(ErrVersionConflict, err)
+	tt.Eq("UPDATE version_user SET age=age+1 WHERE id=? AND age=?", fdb.Execs()[len(fdb.Execs())-1])
+}
+
+type testSoftUser struct {
+	testUser
+}
+
+func (u *testSoftUser) Table() string {
+	return "soft_user"
+}
+
+func (u *testSoftUser) SoftDeleteField() uint64 {
+	return testUserAge
+}
+
+func TestSoftDelete(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("softdelete")
+	fdb.query = func(string, []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"count"}, [][]driver.Value{{int64(1)}}
+	}
+	tt.Eq(uint64(4), db.Table(&testTagModel{}).softDelete)
+
+	last := func() string {
+		return fdb.Execs()[len(fdb.Execs())-1]
+	}
+	u := &testSoftUser{testUser{Id: 1}}
+	tt.Eq("UPDATE soft_user SET age=? WHERE id=? AND age IS NULL", db.Table(u).SQLDelete(nil, 0, testUserId))
+
+	_, err := db.Delete(u, testUserId)
+	tt.Nil(err)
+	tt.Eq("UPDATE soft_user SET age=? WHERE id=? AND age IS NULL", last())
+	_, err = db.HardDelete(u, testUserId)
+	tt.Nil(err)
+	tt.Eq("DELETE FROM soft_user WHERE id=?", last())
+
+	_, err = db.Count(u, testUserId)
+	tt.Nil(err)
+	tt.Eq("SELECT COUNT(*) FROM soft_user WHERE id=? AND age IS NULL", last())
+	_, err = db.Count(WithOps(Unscoped(u), Where(testUserId, GT)), testUserId)
+	tt.Nil(err)
+	tt.Eq("SELECT COUNT(*) FROM soft_user WHERE id>?", last())
+}
+
+const (
+	testTimeUserId uint64 = 1 << iota
+	testTimeUserName
+	testTimeUserCreatedAt
+	testTimeUserUpdatedAt
+)
+
+type testTimeUser struct {
+	Id        int64
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+func (u *testTimeUser) Table() string {
+	return "time_user"
+}
+
+func (u *testTimeUser) Columns() []string {
+	return []string{"id", "name", "created_at", "updated_at"}
+}
+
+func (u *testTimeUser) AutoCreateFields() uint64 {
+	return testTimeUserCreatedAt
+}
+
+func (u *testTimeUser) AutoUpdateFields() uint64 {
+	return testTimeUserUpdatedAt
+}
+
+func (u *testTimeUser) Vals(fields uint64, vals []interface{}) {
+	index := 0
+	for _, v := range []interface{}{u.Id, u.Name, u.CreatedAt, u.UpdatedAt} {
+		if fields&1 != 0 {
+			vals[index] = v
+			index++
+		}
+		fields >>= 1
+	}
+}
+
+func (u *testTimeUser) Ptrs(fields uint64, ptrs []interface{}) {
+	index := 0
+	for _, p := range []interface{}{&u.Id, &u.Name, &u.CreatedAt, &u.UpdatedAt} {
+		if fields&1 != 0 {
+			ptrs[index] = p
+			index++
+		}
+		fields >>= 1
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("timestamp")
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	db.Clock = func() time.Time { return now }
+	last := func() string {
+		return fdb.Execs()[len(fdb.Execs())-1]
+	}
+
+	tt.Eq(uint64(8), db.Table(&testTagModel{}).autoUpdate)
+
+	u := &testTimeUser{Id: 1, Name: "abc"}
+	table := db.Table(u)
+	tt.Eq("INSERT INTO time_user(id,name,created_at,updated_at) VALUES(?,?,?,?)", table.SQLInsert(nil, testTimeUserId|testTimeUserName, 0))
+	tt.Eq("UPDATE time_user SET name=?,updated_at=? WHERE id=?", table.SQLUpdate(nil, testTimeUserName, testTimeUserId))
+	tt.DeepEq([]interface{}{"abc", now, int64(1)}, table.autoArgs(stmtKey{sqlType: UPDATE, fields: testTimeUserName}, []interface{}{"abc", int64(1)}, db.now))
+
+	_, err := db.Insert(u, testTimeUserId|testTimeUserName, RES_NO)
+	tt.Nil(err)
+	tt.Eq("INSERT INTO time_user(id,name,created_at,updated_at) VALUES(?,?,?,?)", last())
+	tt.True(u.CreatedAt.Equal(now))
+	tt.True(u.UpdatedAt != nil && u.UpdatedAt.Equal(now))
+
+	_, err = db.ArgsUpdate(u, testTimeUserName, testTimeUserId, "abc", 1)
+	tt.Nil(err)
+	tt.Eq("UPDATE time_user SET name=?,updated_at=? WHERE id=?", last())
+	_, err = db.Update(u, testTimeUserName, testTimeUserId)
+	tt.Nil(err)
+	tt.Eq("UPDATE time_user SET name=?,updated_at=? WHERE id=?", last())
+
+	const upsertSQL = "INSERT INTO time_user(id,name,created_at,updated_at) VALUES(?,?,?,?) ON CONFLICT (id) DO UPDATE SET name,updated_at"
+	_, err = db.ArgsUpsert(u, testTimeUserId|testTimeUserName, testTimeUserId, testTimeUserName, 1, "abc")
+	tt.Nil(err)
+	tt.Eq(upsertSQL, last())
+	u = &testTimeUser{Id: 2, Name: "abc"}
+	_, err = db.Upsert(u, testTimeUserId|testTimeUserName, testTimeUserId, testTimeUserName)
+	tt.Nil(err)
+	tt.Eq(upsertSQL, last())
+	tt.True(u.CreatedAt.Equal(now))
+	// the exist row is keeped if there is no update fields
+	_, err = db.ArgsUpsert(u, testTimeUserId|testTimeUserName, testTimeUserId, 0, 1, "abc")
+	tt.Nil(err)
+	tt.Eq("INSERT INTO time_user(id,name,created_at,updated_at) VALUES(?,?,?,?) ON CONFLICT (id) DO UPDATE SET ", last())
+
+	// auto timestamp arguments are placed after each row
+	tt.DeepEq([]interface{}{int64(1), "a", now, now, int64(2), "b", now, now},
+		table.autoArgs(stmtKey{sqlType: BATCHINSERT, fields: testTimeUserId | testTimeUserName, rows: 2},
+			[]interface{}{int64(1), "a", int64(2), "b"}, db.now))
+	users := []Model{&testTimeUser{Id: 3}, &testTimeUser{Id: 4}, &testTimeUser{Id: 5}}
+	_, _, err = db.BatchInsert(users, testTimeUserId|testTimeUserName)
+	tt.Nil(err)
+	tt.DeepEq([]string{ // 4 placeholders per row, 2 rows per chunk
+		"INSERT INTO time_user(id,name,created_at,updated_at) VALUES(?,?,?,?),(?,?,?,?)",
+		"INSERT INTO time_user(id,name,created_at,updated_at) VALUES(?,?,?,?)",
+	}, fdb.Execs()[len(fdb.Execs())-2:])
+	for _, u := range users {
+		tt.True(u.(*testTimeUser).CreatedAt.Equal(now))
+	}
 }
@@ -0,0 +1,207 @@
+package gomodel
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type (
+	// QueryEvent describes an execution of statement
+	QueryEvent struct {
+		Table    string  // empty for RAW
+		Type     SQLType // type of sql, RAW for sql executed directly or by sql id
+		SQL      string
+		Args     []interface{}
+		Cached   bool // statement or sql of it is got from cache
+		Duration time.Duration
+		Rows     int64 // affected rows of Exec, -1 for Query
+		Err      error
+	}
+
+	// QueryHook is called around every execution of statements of DB and
+	// transactions started from it.
+	QueryHook interface {
+		// BeforeQuery is called before execution, Duration, Rows and Err of
+		// event is not available, the returned context is used for execution and
+		// AfterQuery
+		BeforeQuery(ctx context.Context, event *QueryEvent) context.Context
+		// AfterQuery is called after execution, for Query, it's called after
+		// rows is returned instead of rows is closed
+		AfterQuery(ctx context.Context, event *QueryEvent)
+	}
+
+	// LogHook log executions take at least Threshold with Printf, arguments are
+	// replaced by the result of Redact if it's not nil
+	LogHook struct {
+		Printf    func(format string, v ...interface{})
+		Threshold time.Duration
+		Redact    func(sql string, args []interface{}) []interface{}
+	}
+
+	// stmtInfo describe the statement for hook, metrics and tracing
+	stmtInfo struct {
+		sql     string
+		cached  bool
+		table   string
+		sqlType SQLType
+		sqlid   uint64
+		byId    bool
+	}
+
+	// hookStmt call hook, record metrics and start span around execution of
+	// the statement
+	hookStmt struct {
+		Stmt
+		hook    QueryHook
+		metrics Metrics
+		tracer  Tracer
+		parent  context.Context // context carry the span of transaction
+		info    stmtInfo
+	}
+
+	// hookCall keeps the state of an execution
+	hookCall struct {
+		ctx   context.Context
+		event *QueryEvent
+		start time.Time
+		span  Span
+	}
+)
+
+func (h LogHook) BeforeQuery(ctx context.Context, _ *QueryEvent) context.Context {
+	return ctx
+}
+
+func (h LogHook) AfterQuery(_ context.Context, e *QueryEvent) {
+	if e.Duration < h.Threshold {
+		return
+	}
+
+	args := e.Args
+	if h.Redact != nil {
+		args = h.Redact(e.SQL, args)
+	}
+	h.Printf("SQL: %s, Args: %v, Cached: %t, Duration: %s, Rows: %d, Error: %v",
+		e.SQL, args, e.Cached, e.Duration, e.Rows, e.Err)
+}
+
+// withHook wrap the statement to call hook, record metrics and start span of
+// executor, if there is none of them, the statement is returned directly. For
+// transaction, the span of statement is child of the transaction span.
+func withHook(exec Executor, stmt Stmt, info stmtInfo) Stmt {
+	var (
+		db     *DB
+		parent context.Context
+	)
+	switch e := exec.(type) {
+	case *DB:
+		db = e
+	case *Tx:
+		db = e.db
+		if e.span != nil {
+			parent = e.ctx
+		}
+	}
+	if db == nil || db.Hook == nil && db.Metrics == nil && db.Tracer == nil || stmt == nil {
+		return stmt
+	}
+
+	return &hookStmt{
+		Stmt:    stmt,
+		hook:    db.Hook,
+		metrics: db.Metrics,
+		tracer:  db.Tracer,
+		parent:  parent,
+		info:    info,
+	}
+}
+
+// unwrapHook return the original statement and it's information
+func unwrapHook(stmt Stmt) (Stmt, stmtInfo) {
+	if s, is := stmt.(*hookStmt); is {
+		return s.Stmt, s.info
+	}
+
+	return stmt, stmtInfo{}
+}
+
+func (s *hookStmt) before(ctx context.Context, args []interface{}) hookCall {
+	c := hookCall{
+		event: &QueryEvent{
+			Table:  s.info.table,
+			Type:   s.info.sqlType,
+			SQL:    s.info.sql,
+			Args:   args,
+			Cached: s.info.cached,
+			Rows:   -1,
+		},
+	}
+	if s.tracer != nil {
+		if s.parent != nil {
+			ctx = s.tracer.Inherit(ctx, s.parent)
+		}
+		ctx, c.span = s.tracer.Start(ctx, s.info.span())
+	}
+	if s.hook != nil {
+		ctx = s.hook.BeforeQuery(ctx, c.event)
+	}
+	c.ctx, c.start = ctx, time.Now()
+
+	return c
+}
+
+func (s *hookStmt) after(c hookCall, err error) {
+	e := c.event
+	e.Duration = time.Since(c.start)
+	e.Err = err
+	if s.metrics != nil {
+		s.metrics.Observe(e.Table, e.Type, e.Duration, err)
+	}
+	if s.hook != nil {
+		s.hook.AfterQuery(c.ctx, e)
+	}
+	if c.span != nil {
+		c.span.End(err)
+	}
+}
+
+func (s *hookStmt) Exec(args ...interface{}) (sql.Result, error) {
+	return s.ExecContext(context.Background(), args...)
+}
+
+func (s *hookStmt) Query(args ...interface{}) (*sql.Rows, error) {
+	return s.QueryContext(context.Background(), args...)
+}
+
+func (s *hookStmt) QueryRow(args ...interface{}) *sql.Row {
+	return s.QueryRowContext(context.Background(), args...)
+}
+
+func (s *hookStmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	c := s.before(ctx, args)
+	res, err := s.Stmt.ExecContext(c.ctx, args...)
+	c.event.Rows = 0
+	if err == nil {
+		c.event.Rows, _ = res.RowsAffected()
+	}
+	s.after(c, err)
+
+	return res, err
+}
+
+func (s *hookStmt) QueryContext(ctx context.Context, args ...interface{}) (*sql.Rows, error) {
+	c := s.before(ctx, args)
+	rows, err := s.Stmt.QueryContext(c.ctx, args...)
+	s.after(c, err)
+
+	return rows, err
+}
+
+func (s *hookStmt) QueryRowContext(ctx context.Context, args ...interface{}) *sql.Row {
+	c := s.before(ctx, args)
+	row := s.Stmt.QueryRowContext(c.ctx, args...)
+	s.after(c, row.Err())
+
+	return row
+}
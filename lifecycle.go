@@ -0,0 +1,110 @@
+package gomodel
+
+import "context"
+
+// Lifecycle hooks of models, they are honored by Insert, InsertReturning,
+// Update, UpdateReturning, Delete and their Args*, *Context variants of DB and
+// Tx. Upsert, BatchInsert and BatchInsertReturning call the insert hooks, the
+// hooks of BatchInsert are called for each model, the after hooks are called
+// after all models are inserted. For variants read arguments from model, the
+// before hooks are called before arguments are extracted, so they can modify
+// the model; for Args* variants, arguments are given by caller, changes of
+// model don't affect them. If a hook return an error, the operation is aborted
+// and the error is returned, inside TxDo, the transaction is rollbacked.
+type (
+	BeforeInserter interface {
+		BeforeInsert(ctx context.Context, exec Executor) error
+	}
+
+	AfterInserter interface {
+		AfterInsert(ctx context.Context, exec Executor) error
+	}
+
+	BeforeUpdater interface {
+		BeforeUpdate(ctx context.Context, exec Executor) error
+	}
+
+	AfterUpdater interface {
+		AfterUpdate(ctx context.Context, exec Executor) error
+	}
+
+	BeforeDeleter interface {
+		BeforeDelete(ctx context.Context, exec Executor) error
+	}
+
+	AfterDeleter interface {
+		AfterDelete(ctx context.Context, exec Executor) error
+	}
+
+	// AfterScanner is called after a row is scanned to the model by One, Scan,
+	// Each of Scanner, and scans of ModelStore
+	AfterScanner interface {
+		AfterScan() error
+	}
+
+	// ModelStore is a Store can return the model at the index, it's used to call
+	// AfterScanner of models
+	ModelStore interface {
+		Store
+		Model(index int) Model
+	}
+)
+
+// beforeHook call the before hook of model for the sql type
+func beforeHook(ctx context.Context, exec Executor, model Model, sqlType SQLType) error {
+	model, _ = unwrapOps(model)
+	switch sqlType {
+	case INSERT:
+		if h, is := model.(BeforeInserter); is {
+			return h.BeforeInsert(ctx, exec)
+		}
+	case UPDATE:
+		if h, is := model.(BeforeUpdater); is {
+			return h.BeforeUpdate(ctx, exec)
+		}
+	case DELETE:
+		if h, is := model.(BeforeDeleter); is {
+			return h.BeforeDelete(ctx, exec)
+		}
+	}
+
+	return nil
+}
+
+// afterHook call the after hook of model for the sql type if the operation is
+// succeed
+func afterHook(ctx context.Context, exec Executor, model Model, sqlType SQLType, n int64, err error) (int64, error) {
+	if err != nil {
+		return n, err
+	}
+
+	model, _ = unwrapOps(model)
+	switch sqlType {
+	case INSERT:
+		if h, is := model.(AfterInserter); is {
+			err = h.AfterInsert(ctx, exec)
+		}
+	case UPDATE:
+		if h, is := model.(AfterUpdater); is {
+			err = h.AfterUpdate(ctx, exec)
+		}
+	case DELETE:
+		if h, is := model.(AfterDeleter); is {
+			err = h.AfterDelete(ctx, exec)
+		}
+	}
+
+	return n, err
+}
+
+// afterScan call AfterScanner of model
+func afterScan(model interface{}) error {
+	if m, is := model.(opsModel); is {
+		model = m.Model
+	}
+	if h, is := model.(AfterScanner); is {
+		return h.AfterScan()
+	}
+
+	return nil
+}
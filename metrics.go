@@ -0,0 +1,155 @@
+package gomodel
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// Metrics record executions of statements, it must be safe for concurrent
+	// use
+	Metrics interface {
+		// Observe record an execution of sqlType statement for table, the table
+		// is empty for RAW
+		Observe(table string, sqlType SQLType, duration time.Duration, err error)
+	}
+
+	// Snapshot is the statistics of connection pool and statement cache
+	Snapshot struct {
+		Pool  sql.DBStats
+		Cache CacheStats
+	}
+
+	// MetricsKey identify the statements recorded by MemMetrics
+	MetricsKey struct {
+		Table string
+		Type  SQLType
+	}
+
+	// StmtMetrics is the counters and latency histogram of statements
+	StmtMetrics struct {
+		Count  uint64
+		Errors uint64
+		Total  time.Duration
+		// Buckets[i] is the count of executions take no more than Bounds[i],
+		// the last one is the count of executions exceed all bounds
+		Buckets []uint64
+	}
+
+	// MemMetrics keeps metrics in memory, it's mainly used for tests and
+	// exposing by custom endpoints
+	MemMetrics struct {
+		// Bounds is the upper bounds of latency histogram buckets in ascending
+		// order, default DefaultLatencyBounds, it should be set before use
+		Bounds []time.Duration
+
+		mu    sync.Mutex
+		stmts map[MetricsKey]*StmtMetrics
+	}
+)
+
+// DefaultLatencyBounds is the default upper bounds of latency histogram
+var DefaultLatencyBounds = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// Snapshot return the statistics of connection pool and statement cache
+func (db *DB) Snapshot() Snapshot {
+	return Snapshot{
+		Pool:  db.DB.Stats(),
+		Cache: db.CacheStats(),
+	}
+}
+
+// NewMemMetrics create a MemMetrics use DefaultLatencyBounds
+func NewMemMetrics() *MemMetrics {
+	return &MemMetrics{Bounds: DefaultLatencyBounds}
+}
+
+func (m *MemMetrics) Observe(table string, sqlType SQLType, duration time.Duration, err error) {
+	bucket := sort.Search(len(m.Bounds), func(i int) bool {
+		return duration <= m.Bounds[i]
+	})
+
+	m.mu.Lock()
+	key := MetricsKey{Table: table, Type: sqlType}
+	s, has := m.stmts[key]
+	if !has {
+		if m.stmts == nil {
+			m.stmts = make(map[MetricsKey]*StmtMetrics)
+		}
+		s = &StmtMetrics{Buckets: make([]uint64, len(m.Bounds)+1)}
+		m.stmts[key] = s
+	}
+	s.Count++
+	if err != nil {
+		s.Errors++
+	}
+	s.Total += duration
+	s.Buckets[bucket]++
+	m.mu.Unlock()
+}
+
+// Stmts return a copy of metrics of each table and sql type
+func (m *MemMetrics) Stmts() map[MetricsKey]StmtMetrics {
+	return m.group(func(key MetricsKey) MetricsKey { return key })
+}
+
+// ByType return a copy of metrics grouped by sql type
+func (m *MemMetrics) ByType() map[SQLType]StmtMetrics {
+	res := make(map[SQLType]StmtMetrics)
+	for key, s := range m.group(func(key MetricsKey) MetricsKey { return MetricsKey{Type: key.Type} }) {
+		res[key.Type] = s
+	}
+
+	return res
+}
+
+// ByTable return a copy of metrics grouped by table, RAW statements are
+// grouped to the empty table
+func (m *MemMetrics) ByTable() map[string]StmtMetrics {
+	res := make(map[string]StmtMetrics)
+	for key, s := range m.group(func(key MetricsKey) MetricsKey { return MetricsKey{Table: key.Table} }) {
+		res[key.Table] = s
+	}
+
+	return res
+}
+
+// Reset remove all recorded metrics
+func (m *MemMetrics) Reset() {
+	m.mu.Lock()
+	m.stmts = nil
+	m.mu.Unlock()
+}
+
+func (m *MemMetrics) group(keyOf func(MetricsKey) MetricsKey) map[MetricsKey]StmtMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make(map[MetricsKey]StmtMetrics, len(m.stmts))
+	for key, s := range m.stmts {
+		key = keyOf(key)
+		g := res[key]
+		if g.Buckets == nil {
+			g.Buckets = make([]uint64, len(s.Buckets))
+		}
+		g.Count += s.Count
+		g.Errors += s.Errors
+		g.Total += s.Total
+		for i, n := range s.Buckets {
+			g.Buckets[i] += n
+		}
+		res[key] = g
+	}
+
+	return res
+}
@@ -29,6 +29,39 @@ type (
 	Nocacher interface {
 		Nocache() bool
 	}
+
+	// Versioner is a optional interface for Model, it return the version field
+	// used for optimistic locking, it's same as tag `version:"true"` of the
+	// field, mainly for Columner models.
+	//
+	// The version field is increased by each Update, and the current value is
+	// added as condition, if no rows is affected, ErrVersionConflict is returned.
+	Versioner interface {
+		VersionField() uint64
+	}
+
+	// SoftDeleter is a optional interface for Model, it return the soft delete
+	// field, it's same as tag `softdelete:"true"` of the field, mainly for
+	// Columner models.
+	//
+	// The soft delete field is a nullable time column like deleted_at, Delete
+	// set it to current time instead of removing rows, and rows have non-null
+	// value are filtered out by other operations, use Unscoped to disable it.
+	SoftDeleter interface {
+		SoftDeleteField() uint64
+	}
+
+	// Timestamper is a optional interface for Model, it return the auto
+	// timestamp fields, it's same as tag `autocreate:"true"` and
+	// `autoupdate:"true"` of fields, mainly for Columner models.
+	//
+	// Auto create fields are set to current time by Insert, Upsert and
+	// BatchInsert, auto update fields are also set by Update and the update
+	// part of Upsert, even if they are not in the fields.
+	Timestamper interface {
+		AutoCreateFields() uint64
+		AutoUpdateFields() uint64
+	}
 )
 
 func NumFields(n uint64) int {
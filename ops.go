@@ -0,0 +1,214 @@
+package gomodel
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type (
+	// Op is the comparison operator of a where field
+	Op uint8
+
+	// Ops keeps the where fields of each operator, fields not in Ops use EQ.
+	// Ops is comparable, it's also part of statement identity.
+	Ops [_OP_COUNT]uint64
+
+	// opsModel attach operators to where fields of model, or disable the soft
+	// delete scope
+	opsModel struct {
+		Model
+		ops      Ops
+		unscoped bool
+	}
+)
+
+const (
+	EQ      Op = iota // column=?
+	NE                // column<>?
+	LT                // column<?
+	LE                // column<=?
+	GT                // column>?
+	GE                // column>=?
+	LIKE              // column LIKE ?
+	ISNULL            // column IS NULL, no argument
+	NOTNULL           // column IS NOT NULL, no argument
+	IN                // column IN(?, ...), argument is a slice expanded to placeholders
+	_OP_COUNT
+)
+
+var opConds = [_OP_COUNT]string{
+	EQ:      "=?",
+	NE:      "<>?",
+	LT:      "<?",
+	LE:      "<=?",
+	GT:      ">?",
+	GE:      ">=?",
+	LIKE:    " LIKE ?",
+	ISNULL:  " IS NULL",
+	NOTNULL: " IS NOT NULL",
+	IN:      " IN",
+}
+
+// Where create operators use op for fields
+func Where(fields uint64, op Op) Ops {
+	return Ops{}.Set(fields, op)
+}
+
+// In create operators use IN for fields, the arguments of fields must be slices
+func In(fields uint64) Ops {
+	return Where(fields, IN)
+}
+
+// Set use op for fields, previous operators of fields are replaced
+func (o Ops) Set(fields uint64, op Op) Ops {
+	for i := range o {
+		o[i] &^= fields
+	}
+	if op != EQ {
+		o[op] |= fields
+	}
+
+	return o
+}
+
+// Of return operator of field
+func (o Ops) Of(field uint64) Op {
+	for i, fields := range o {
+		if fields&field != 0 {
+			return Op(i)
+		}
+	}
+
+	return EQ
+}
+
+// ArgFields return the where fields need arguments, fields use ISNULL and
+// NOTNULL are excluded
+func (o Ops) ArgFields(whereFields uint64) uint64 {
+	return whereFields &^ (o[ISNULL] | o[NOTNULL])
+}
+
+// WithOps attach operators of where fields to model, the returned model can be
+// used for all Executor operations accept where fields, values of where fields
+// use ISNULL, NOTNULL will not be extracted as arguments.
+//
+// For Args* operations, arguments should match the where fields except which
+// use ISNULL, NOTNULL.
+func WithOps(model Model, ops Ops) Model {
+	if m, is := model.(opsModel); is {
+		m.ops = ops
+		return m
+	}
+
+	return opsModel{Model: model, ops: ops}
+}
+
+// unwrapOps return the original model and attached operators
+func unwrapOps(model Model) (Model, Ops) {
+	if m, is := model.(opsModel); is {
+		return m.Model, m.ops
+	}
+
+	return model, Ops{}
+}
+
+// whereVals extract values of where fields which need arguments
+func whereVals(model Model, whereFields uint64, args ...interface{}) []interface{} {
+	_, ops := unwrapOps(model)
+
+	return FieldVals(model, ops.ArgFields(whereFields), args...)
+}
+
+// OpsWhere is similar to Where, but use operators of ops for fields, fields use
+// IN have only one placeholder
+func (t *Table) OpsWhere(fields uint64, ops Ops) string {
+	return t.scopeWhere(t.opsWhere(fields, ops, ""))
+}
+
+// opsWhere create where clause, ins is the arity bucket of each IN fields, see
+// expandIn
+func (t *Table) opsWhere(fields uint64, ops Ops, ins string) string {
+	if ops == (Ops{}) {
+		return where(t.Cols(fields))
+	}
+
+	var conds []string
+	for i, l := uint64(0), uint64(len(t.columns)); i < l; i++ {
+		if (1<<i)&fields == 0 {
+			continue
+		}
+
+		op := ops.Of(1 << i)
+		cond := t.columns[i] + opConds[op]
+		if op == IN {
+			var bucket byte
+			if ins != "" {
+				bucket, ins = ins[0], ins[1:]
+			}
+			cond += "(" + strings.Repeat("?, ", 1<<bucket-1) + "?)"
+		}
+		conds = append(conds, cond)
+	}
+	if len(conds) == 0 {
+		return ""
+	}
+
+	return "WHERE " + strings.Join(conds, " AND ")
+}
+
+// expandIn expand slice arguments of IN fields to single values, the slice
+// length is rounded up to power of 2 by repeating the last value, so the
+// statements are cached per arity bucket instead of each length, the bucket
+// of each IN fields are recorded in key.
+func (key *stmtKey) expandIn(args []interface{}) ([]interface{}, error) {
+	inFields := key.whereFields & key.ops[IN]
+	if inFields == 0 {
+		return args, nil
+	}
+
+	var start int
+	switch key.sqlType {
+	case UPDATE, INCRBY:
+		start = NumFields(key.fields)
+	}
+	argFields := key.ops.ArgFields(key.whereFields)
+	if start+NumFields(argFields) > len(args) {
+		return nil, fmt.Errorf("need at least %d arguments, but only got %d", start+NumFields(argFields), len(args))
+	}
+
+	expanded := append(make([]interface{}, 0, len(args)), args[:start]...)
+	ins := make([]byte, 0, NumFields(inFields))
+	for i, pos := uint(0), start; i < 64; i++ {
+		field := uint64(1) << i
+		if argFields&field == 0 {
+			continue
+		}
+
+		arg := args[pos]
+		pos++
+		if inFields&field == 0 {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		v := reflect.ValueOf(arg)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array || v.Len() == 0 {
+			return nil, fmt.Errorf("argument of IN field must be a non-empty slice, but got %v", arg)
+		}
+		var bucket byte
+		for n := v.Len(); 1<<bucket < n; bucket++ {
+		}
+		for j, n := 0, v.Len(); j < 1<<bucket; j++ {
+			if j < n {
+				expanded = append(expanded, v.Index(j).Interface())
+			} else {
+				expanded = append(expanded, v.Index(n-1).Interface())
+			}
+		}
+		ins = append(ins, bucket)
+	}
+	key.ins = string(ins)
+
+	return append(expanded, args[start+NumFields(argFields):]...), nil
+}
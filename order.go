@@ -0,0 +1,90 @@
+package gomodel
+
+import "context"
+
+// Order describe the ORDER BY clause of select sql, columns are sorted by the
+// declared order of fields in model, a field is sorted in descending order if
+// it's also in DescFields, otherwise ascending order.
+type Order struct {
+	Fields     uint64
+	DescFields uint64
+}
+
+// Asc create an order sort fields in ascending order
+func Asc(fields uint64) Order {
+	return Order{Fields: fields}
+}
+
+// Desc create an order sort fields in descending order
+func Desc(fields uint64) Order {
+	return Order{Fields: fields, DescFields: fields}
+}
+
+// Asc add fields sorted in ascending order
+func (o Order) Asc(fields uint64) Order {
+	o.Fields |= fields
+	o.DescFields &^= fields
+
+	return o
+}
+
+// Desc add fields sorted in descending order
+func (o Order) Desc(fields uint64) Order {
+	o.Fields |= fields
+	o.DescFields |= fields
+
+	return o
+}
+
+// OrderBy create order by clause for given order, the 'ORDER BY' word is included
+func (t *Table) OrderBy(order Order) string {
+	if order.Fields == 0 {
+		return ""
+	}
+
+	s := "ORDER BY "
+	for i, l := uint64(0), uint64(len(t.columns)); i < l; i++ {
+		if (1<<i)&order.Fields == 0 {
+			continue
+		}
+
+		if s != "ORDER BY " {
+			s += ","
+		}
+		s += t.columns[i]
+		if (1<<i)&order.DescFields != 0 {
+			s += " DESC"
+		} else {
+			s += " ASC"
+		}
+	}
+
+	return s
+}
+
+// OrderSQL create select sql of given type with ORDER BY clause, only ONE,
+// LIMIT, ALL are supported
+func (t *Table) OrderSQL(driver Driver, sqlType SQLType, fields, whereFields uint64, order Order) string {
+	switch sqlType {
+	case ONE, LIMIT, ALL:
+		return t.keySQL(driver, stmtKey{sqlType: sqlType, fields: fields, whereFields: whereFields, order: order})
+	}
+
+	panic("unsupported sql type for order")
+}
+
+// OrderStmt is similar to StmtContext, but the sql is created by OrderSQL, the
+// order is also part of statement identity
+func (t *Table) OrderStmt(ctx context.Context, exec Executor, sqlType SQLType, fields, whereFields uint64, order Order) (Stmt, error) {
+	return t.cache.Share(t.stmt(ctx, exec, sqlType, t.keyIdentity(stmtKey{sqlType: sqlType, fields: fields, whereFields: whereFields, order: order}), func(dri Driver) string {
+		return t.OrderSQL(dri, sqlType, fields, whereFields, order)
+	}))
+}
+
+// OrderPrepare is similar to PrepareContext, but the sql is created by OrderSQL,
+// the order is also part of statement identity
+func (t *Table) OrderPrepare(ctx context.Context, exec Executor, sqlType SQLType, fields, whereFields uint64, order Order) (Stmt, error) {
+	return t.prepare(ctx, exec, sqlType, t.keyIdentity(stmtKey{sqlType: sqlType, fields: fields, whereFields: whereFields, order: order}), func(dri Driver) string {
+		return t.OrderSQL(dri, sqlType, fields, whereFields, order)
+	})
+}
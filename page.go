@@ -0,0 +1,132 @@
+package gomodel
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+var (
+	ErrBadCursor = errors.New("bad cursor")
+	ErrBadOrder  = errors.New("order fields must be part of select fields and sorted in same direction")
+)
+
+// keysetWhere append the keyset condition of order to where clause
+func (t *Table) keysetWhere(where string, order Order) string {
+	cols := t.Cols(order.Fields)
+	cond := "(" + cols.String() + ")"
+	if order.DescFields != 0 {
+		cond += " < "
+	} else {
+		cond += " > "
+	}
+	cond += "(" + cols.OnlyParam() + ")"
+
+	if where == "" {
+		return "WHERE " + cond
+	}
+	return where + " AND " + cond
+}
+
+// pageArgs create the statement key of page query, the values of cursor are
+// appended to arguments, followed by the row count
+func pageArgs(model Model, fields, whereFields uint64, order Order, cursor string, count int, args []interface{}) (stmtKey, []interface{}, error) {
+	key := stmtKey{sqlType: PAGE, fields: fields, whereFields: whereFields, order: order}
+	if order.Fields == 0 || order.Fields&fields != order.Fields ||
+		(order.DescFields != 0 && order.DescFields != order.Fields) {
+		return key, nil, ErrBadOrder
+	}
+
+	if cursor != "" {
+		if err := decodeCursor(cursor, FieldPtrs(model, order.Fields)); err != nil {
+			return key, nil, err
+		}
+		key.keyset = true
+		args = append(args, FieldVals(model, order.Fields)...)
+	}
+
+	return key, append(args, count), nil
+}
+
+// pageStore record the final row count of Store
+type pageStore struct {
+	Store
+	size int
+}
+
+func (s *pageStore) Final(size int) {
+	s.size = size
+	s.Store.Final(size)
+}
+
+// Model return the model at index if the underlying store is ModelStore
+func (s *pageStore) Model(index int) Model {
+	if ms, is := s.Store.(ModelStore); is {
+		return ms.Model(index)
+	}
+
+	return nil
+}
+
+// scanPage scan rows to store, the cursor is encoded from values of order
+// fields in the last row if rows count reach the limit. No rows is not an
+// error, the store is finalized with 0 rows and the cursor is "".
+func scanPage(scanner Scanner, store Store, fields uint64, order Order, count int) (string, error) {
+	s := &pageStore{Store: store}
+	err := scanner.Limit(s, count)
+	if err == sql.ErrNoRows {
+		store.Final(0)
+		return "", nil
+	}
+	if err != nil || s.size < count {
+		return "", err
+	}
+
+	ptrs := make([]interface{}, NumFields(fields))
+	store.Ptrs(s.size-1, ptrs)
+
+	vals := make([]interface{}, 0, NumFields(order.Fields))
+	for i, index := uint(0), 0; i < 64; i++ {
+		field := uint64(1) << i
+		if fields&field == 0 {
+			continue
+		}
+		if order.Fields&field != 0 {
+			vals = append(vals, reflect.ValueOf(ptrs[index]).Elem().Interface())
+		}
+		index++
+	}
+
+	return encodeCursor(vals)
+}
+
+func encodeCursor(vals []interface{}) (string, error) {
+	data, err := json.Marshal(vals)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor decode values of cursor to pointers
+func decodeCursor(cursor string, ptrs []interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrBadCursor
+	}
+
+	var vals []json.RawMessage
+	if err = json.Unmarshal(data, &vals); err != nil || len(vals) != len(ptrs) {
+		return ErrBadCursor
+	}
+	for i, val := range vals {
+		if err = json.Unmarshal(val, ptrs[i]); err != nil {
+			return ErrBadCursor
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package gomodel
+
+import (
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cosiner/gohper/testing2"
+)
+
+// run with 'go test -race' to detect data races of shared DB
+func TestParallelCRUD(t *testing.T) {
+	tt := testing2.Wrap(t)
+	db, fdb := openFake("parallel")
+	fdb.query = func(sql string, _ []driver.Value) ([]string, [][]driver.Value) {
+		if strings.HasPrefix(sql, "SELECT COUNT") {
+			return []string{"count"}, [][]driver.Value{{int64(2)}}
+		}
+
+		cols := strings.Split(sql[len("SELECT "):strings.Index(sql, " FROM")], ",")
+		rows := make([][]driver.Value, 2)
+		for i := range rows {
+			for _, col := range cols {
+				if col == "name" {
+					rows[i] = append(rows[i], "abc")
+				} else {
+					rows[i] = append(rows[i], int64(i))
+				}
+			}
+		}
+		return cols, rows
+	}
+
+	const N = 16
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, N*8)
+	)
+	for i := 0; i < N; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			u := &testUser{Id: int64(i), Name: "abc", Age: i}
+			_, err := db.Insert(u, testUserFieldsAll, RES_ROWS)
+			errs <- err
+			_, err = db.Update(u, testUserName|testUserAge, testUserId)
+			errs <- err
+			errs <- db.One(u, testUserFieldsAll, testUserId)
+
+			var users testUserStore
+			errs <- db.All(&users, u, testUserFieldsAll, testUserAge)
+			if len(users.Values) != 2 {
+				t.Errorf("expect 2 users, got %d", len(users.Values))
+			}
+			_, err = db.Count(u, testUserAge)
+			errs <- err
+			_, err = db.Delete(u, testUserId)
+			errs <- err
+
+			errs <- db.TxDo(func(tx *Tx) error {
+				_, err := tx.Update(u, testUserName, testUserId)
+				if err == nil {
+					err = tx.One(u, testUserName, testUserId)
+				}
+				return err
+			})
+			if l := db.Table(u).Cols(testUserFieldsAll).Length(); l != 3 {
+				t.Errorf("expect 3 columns, got %d", l)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		tt.Nil(err)
+	}
+}
@@ -0,0 +1,145 @@
+package gomodel
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// replica is a read replica of DB
+	replica struct {
+		db      *DB
+		weight  int
+		healthy int32
+	}
+
+	// replicaSet route read operations to replicas by weighted round-robin,
+	// unhealthy replicas are skipped
+	replicaSet struct {
+		list atomic.Value // []*replica, copy on write
+		next uint64
+
+		mu   sync.Mutex
+		stop chan struct{} // stop health checking
+	}
+)
+
+// AddReplica add a read replica with the weight, One, Limit, All, Iter, Page,
+// Count, Exists and QueryById of DB are routed to replicas, other operations
+// and all transactions use the DB itself. Replicas with equal weights are
+// selected by round-robin, if there is no healthy replicas, the DB itself is
+// used. Each replica has it's own statement cache, hook, metrics and tracer.
+func (db *DB) AddReplica(replica_ *DB, weight int) {
+	if weight <= 0 {
+		weight = 1
+	}
+
+	db.replicas.mu.Lock()
+	list, _ := db.replicas.list.Load().([]*replica)
+	list = append(list[:len(list):len(list)], &replica{db: replica_, weight: weight, healthy: 1})
+	db.replicas.list.Store(list)
+	db.replicas.mu.Unlock()
+}
+
+// CheckReplicas ping replicas with the interval in background, replicas failed
+// to response in the interval are marked unhealthy until next successful ping.
+// The checking is stopped when DB is closed.
+func (db *DB) CheckReplicas(interval time.Duration) {
+	db.replicas.mu.Lock()
+	defer db.replicas.mu.Unlock()
+	if db.replicas.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	db.replicas.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				db.replicas.check(interval)
+			}
+		}
+	}()
+}
+
+// check ping all replicas and update health status
+func (rs *replicaSet) check(timeout time.Duration) {
+	list, _ := rs.list.Load().([]*replica)
+	for _, r := range list {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		healthy := int32(1)
+		if r.db.PingContext(ctx) != nil {
+			healthy = 0
+		}
+		cancel()
+		atomic.StoreInt32(&r.healthy, healthy)
+	}
+}
+
+// pick select a healthy replica, nil is returned if there is none
+func (rs *replicaSet) pick() *DB {
+	list, _ := rs.list.Load().([]*replica)
+	if len(list) == 0 {
+		return nil
+	}
+
+	var total int
+	for _, r := range list {
+		if atomic.LoadInt32(&r.healthy) != 0 {
+			total += r.weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	n := int(atomic.AddUint64(&rs.next, 1) % uint64(total))
+	for _, r := range list {
+		if atomic.LoadInt32(&r.healthy) == 0 {
+			continue
+		}
+		if n < r.weight {
+			return r.db
+		}
+		n -= r.weight
+	}
+
+	return nil
+}
+
+// close stop health checking and close all replicas
+func (rs *replicaSet) close() error {
+	rs.mu.Lock()
+	if rs.stop != nil {
+		close(rs.stop)
+		rs.stop = nil
+	}
+	rs.mu.Unlock()
+
+	var err error
+	list, _ := rs.list.Load().([]*replica)
+	for _, r := range list {
+		if e := r.db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// reader return a healthy replica for read operations, or the DB itself
+func (db *DB) reader() *DB {
+	if r := db.replicas.pick(); r != nil {
+		return r
+	}
+
+	return db
+}
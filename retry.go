@@ -0,0 +1,61 @@
+package gomodel
+
+import (
+	"context"
+	"time"
+)
+
+// Retry configure the retrying of transaction, transaction is retried only if
+// the error is retryable reported by Driver.Retryable
+type Retry struct {
+	// Attempts is the max count of running transaction, at least 1
+	Attempts int
+	// Backoff is the waiting duration before first retry, it's doubled for
+	// each retry
+	Backoff time.Duration
+	// MaxBackoff is the max waiting duration, 0 means no limit
+	MaxBackoff time.Duration
+	// Options is used to start each transaction
+	Options TxOptions
+}
+
+// DefaultRetry retry transaction at most 3 times
+var DefaultRetry = Retry{
+	Attempts:   3,
+	Backoff:    10 * time.Millisecond,
+	MaxBackoff: time.Second,
+}
+
+// RetryTxDo is similar to TxDo, but the transaction will be retried if failed by
+// deadlock or serialization failure, the function may be called multiple
+// times, so it should not have side effects out of the transaction
+func (db *DB) RetryTxDo(retry Retry, do func(*Tx) error) error {
+	return db.RetryTxDoContext(context.Background(), retry, do)
+}
+
+// RetryTxDoContext is similar to RetryTxDo, but start transactions with the
+// context, the retrying is stopped if context is done
+func (db *DB) RetryTxDoContext(ctx context.Context, retry Retry, do func(*Tx) error) error {
+	backoff := retry.Backoff
+	for attempt := 1; ; attempt++ {
+		err := db.TxDoWithContext(ctx, retry.Options, do)
+		if err == nil || attempt >= retry.Attempts || !db.driver.Retryable(err) {
+			return err
+		}
+
+		if backoff > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+
+			backoff *= 2
+			if retry.MaxBackoff > 0 && backoff > retry.MaxBackoff {
+				backoff = retry.MaxBackoff
+			}
+		}
+	}
+}
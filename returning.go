@@ -0,0 +1,79 @@
+package gomodel
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrReturningUnsupported means the driver don't support RETURNING clause, and
+// the returning fields can't be resolved by LastInsertId
+var ErrReturningUnsupported = errors.New("returning fields is not supported by driver")
+
+// execReturning execute insert/update statement, values of returnFields in
+// the first row are scanned to model, the count of affected rows was returned.
+//
+// If driver don't support RETURNING clause, only insert with one returning
+// field is available, it's resolved by LastInsertId.
+func execReturning(ctx context.Context, exec Executor, stmt Stmt, err error, sqlType SQLType, model Model, returnFields uint64, args []interface{}) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	ptrs := FieldPtrs(model, returnFields)
+	if exec.Driver().SQLReturning(exec.Table(model).Cols(returnFields).Names()) == "" {
+		if sqlType != INSERT || len(ptrs) != 1 {
+			return 0, ErrReturningUnsupported
+		}
+
+		res, err := stmt.ExecContext(ctx, args...)
+		id, err := ResolveResult(res, err, RES_ID)
+		if err == nil {
+			err = assignId(ptrs[0], id)
+		}
+		return 1, err
+	}
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var count int64
+	for rows.Next() {
+		if count == 0 {
+			if err = rows.Scan(ptrs...); err != nil {
+				return 0, err
+			}
+		}
+		count++
+	}
+
+	return count, rows.Err()
+}
+
+// assignId store the last insert id to the pointer of field
+func assignId(ptr interface{}, id int64) error {
+	if s, is := ptr.(sql.Scanner); is {
+		return s.Scan(id)
+	}
+
+	v := reflect.ValueOf(ptr)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(id)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(uint64(id))
+			return nil
+		}
+	}
+
+	return fmt.Errorf("can't store insert id to %T", ptr)
+}
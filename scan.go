@@ -33,13 +33,78 @@ type (
 	}
 )
 
+// Close close the rows and statement, it must be called if rows is not
+// iterated to the end
 func (sc Scanner) Close() {
-	stmt := sc.Stmt
-	sc.Stmt = nil
+	if sc.Rows != nil {
+		sc.Rows.Close()
+	}
+	if sc.Stmt != nil {
+		sc.Stmt.Close()
+	}
+}
 
-	if stmt != nil {
-		stmt.Close()
+// Next prepare the next row for Scan, if there is no more rows or error
+// happened, false was returned and the rows and statement are closed,
+// check Err for the error.
+//
+//	defer scanner.Close() // in case of early exit
+//	for scanner.Next() {
+//	    if err := scanner.Scan(model, fields); err != nil {
+//	        return err
+//	    }
+//	}
+//	return scanner.Err()
+func (sc Scanner) Next() bool {
+	if sc.Error != nil || !sc.Rows.Next() {
+		sc.Close()
+		return false
 	}
+
+	return true
+}
+
+// Scan scan current row to fields of model
+func (sc Scanner) Scan(model Model, fields uint64) error {
+	if err := sc.Rows.Scan(FieldPtrs(model, fields)...); err != nil {
+		return err
+	}
+
+	return afterScan(model)
+}
+
+// Err return the error happened during query or iteration
+func (sc Scanner) Err() error {
+	if sc.Error != nil {
+		return sc.Error
+	}
+
+	return sc.Rows.Err()
+}
+
+// Each scan rows one by one to the same model and call fn for each row, the
+// iteration stop if fn return an error, the rows and statement are always
+// closed
+func (sc Scanner) Each(model Model, fields uint64, fn func() error) error {
+	defer sc.Close()
+	if sc.Error != nil {
+		return sc.Error
+	}
+
+	ptrs := FieldPtrs(model, fields)
+	for sc.Rows.Next() {
+		if err := sc.Rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		if err := afterScan(model); err != nil {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	return sc.Rows.Err()
 }
 
 func _rowCount(c int) int {
@@ -90,9 +155,17 @@ func (sc Scanner) multiple(s Store, count int, scanType bool) error {
 		if err = rows.Scan(ptrs...); err != nil {
 			return err
 		}
+		if ms, is := s.(ModelStore); is {
+			if err = afterScan(ms.Model(index)); err != nil {
+				return err
+			}
+		}
 		index++
 	}
 
+	if err = rows.Err(); err != nil { // context canceled or other errors
+		return err
+	}
 	if index == 0 {
 		err = sql.ErrNoRows
 	} else {
@@ -122,7 +195,7 @@ func (sc Scanner) One(ptrs ...interface{}) error {
 	var err error
 	if rows.Next() {
 		err = rows.Scan(ptrs...)
-	} else {
+	} else if err = rows.Err(); err == nil {
 		err = sql.ErrNoRows
 	}
 
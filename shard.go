@@ -0,0 +1,829 @@
+package gomodel
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type (
+	// ShardFunc return the shard index for an operation. For operations of
+	// model, fields are where fields of the operation, or insert fields for
+	// Insert, Upsert and their variants; args are the arguments of fields for
+	// Args* operations, or nil, then values should be read from model.
+	// For operations by sql or sql id, model is nil and args are all arguments.
+	ShardFunc func(model Model, fields uint64, args []interface{}) (int, error)
+
+	// Shards is an Executor route each operation to one of DBs by ShardFunc, a
+	// shard index attached to context by WithShard take precedence over it.
+	// The DBs should use the same driver and schema.
+	Shards struct {
+		shardExec
+		dbs   []*DB
+		shard ShardFunc
+	}
+
+	// ShardTx is an Executor run operations in a transaction of one shard, the
+	// transaction is started on the shard of first operation, operations
+	// routed to other shards fail with ErrCrossShard.
+	//
+	// ShardTx is not safe for concurrent use, the shard is bound lazily by the
+	// first operation without synchronization.
+	ShardTx struct {
+		shardExec
+		opts  TxOptions
+		tx    *Tx
+		index int
+	}
+
+	// shardExec implements Executor, each operation is delegated to the
+	// executor of shard picked for it, operations without context use ctx
+	shardExec struct {
+		shards *Shards
+		ctx    context.Context
+		exec   func(ctx context.Context, index int) (Executor, error)
+	}
+
+	shardKey struct{}
+)
+
+var (
+	// ErrCrossShard means operations of a transaction are routed to different
+	// shards
+	ErrCrossShard = errors.New("cross-shard transaction is not supported")
+	// ErrNoShard means the shard of operation can't be determined
+	ErrNoShard = errors.New("no shard for the operation")
+	// ErrNoShards means Shards is created without any DB
+	ErrNoShards = errors.New("no DBs for shards")
+)
+
+// NewShards create Shards use the shard function for DBs, at least one DB is
+// required
+func NewShards(shard ShardFunc, dbs ...*DB) (*Shards, error) {
+	if len(dbs) == 0 {
+		return nil, ErrNoShards
+	}
+
+	s := &Shards{
+		dbs:   dbs,
+		shard: shard,
+	}
+	s.shardExec = shardExec{
+		shards: s,
+		ctx:    context.Background(),
+		exec: func(_ context.Context, index int) (Executor, error) {
+			return dbs[index], nil
+		},
+	}
+
+	return s, nil
+}
+
+// WithShard attach the shard index to context, operations with the context
+// use it instead of calling ShardFunc
+func WithShard(ctx context.Context, index int) context.Context {
+	return context.WithValue(ctx, shardKey{}, index)
+}
+
+// DBs return all shards
+func (s *Shards) DBs() []*DB {
+	return s.dbs
+}
+
+// Index return the shard index for the operation
+func (s *Shards) Index(ctx context.Context, model Model, fields uint64, args []interface{}) (int, error) {
+	index, has := ctx.Value(shardKey{}).(int)
+	if !has {
+		if s.shard == nil {
+			return 0, ErrNoShard
+		}
+
+		model, ops := unwrapOps(model)
+		var err error
+		index, err = s.shard(model, ops.ArgFields(fields), args)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if index < 0 || index >= len(s.dbs) {
+		return 0, fmt.Errorf("shard index %d out of range [0, %d)", index, len(s.dbs))
+	}
+
+	return index, nil
+}
+
+// Close close all shards
+func (s *Shards) Close() error {
+	var err error
+	for _, db := range s.dbs {
+		if e := db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+func (s *Shards) TxDo(do func(*ShardTx) error) error {
+	return s.TxDoWithContext(context.Background(), TxOptions{}, do)
+}
+
+func (s *Shards) TxDoContext(ctx context.Context, do func(*ShardTx) error) error {
+	return s.TxDoWithContext(ctx, TxOptions{}, do)
+}
+
+// TxDoWithContext run function in a transaction of one shard, the shard is
+// decided by the first operation in it. If the function return an error or
+// commit failed, the error is returned.
+func (s *Shards) TxDoWithContext(ctx context.Context, opts TxOptions, do func(*ShardTx) error) error {
+	tx := &ShardTx{opts: opts}
+	tx.shardExec = shardExec{shards: s, ctx: ctx, exec: tx.begin}
+
+	var finished bool
+	defer func() {
+		if tx.tx != nil && !finished { // panic
+			tx.tx.Rollback()
+		}
+	}()
+
+	err := do(tx)
+	finished = true
+	if tx.tx == nil {
+		return err
+	}
+	if err != nil {
+		tx.tx.Rollback()
+		return err
+	}
+
+	return tx.tx.Commit()
+}
+
+// begin start transaction on the shard if it's the first operation, otherwise
+// the shard must be the same as before
+func (tx *ShardTx) begin(_ context.Context, index int) (Executor, error) {
+	if tx.tx != nil {
+		if index != tx.index {
+			return nil, ErrCrossShard
+		}
+
+		return tx.tx, nil
+	}
+
+	t, err := tx.shards.dbs[index].BeginTx(tx.ctx, &tx.opts)
+	if err != nil {
+		return nil, err
+	}
+	tx.tx, tx.index = t, index
+
+	return t, nil
+}
+
+// Tx return the transaction and index of shard, the transaction is nil if
+// there is no operations yet
+func (tx *ShardTx) Tx() (*Tx, int) {
+	return tx.tx, tx.index
+}
+
+// pick the executor for the operation, args are sliced to arguments of fields
+// start at offset
+func (s shardExec) pick(ctx context.Context, model Model, fields uint64, args []interface{}, offset int) (Executor, error) {
+	if args != nil && model != nil {
+		_, ops := unwrapOps(model)
+		end := offset + NumFields(ops.ArgFields(fields))
+		if end > len(args) {
+			end = len(args)
+		}
+		if offset > end {
+			offset = end
+		}
+		args = args[offset:end]
+	}
+
+	index, err := s.shards.Index(ctx, model, fields, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.exec(ctx, index)
+}
+
+// Driver return driver of the first shard
+func (s shardExec) Driver() Driver {
+	return s.shards.dbs[0].Driver()
+}
+
+// Table return table of the first shard, tables of all shards are the same
+func (s shardExec) Table(model Model) *Table {
+	return s.shards.dbs[0].Table(model)
+}
+
+// Prepare prepare sql on the shard attached to context, see WithShard
+func (s shardExec) Prepare(sql string) (*sql.Stmt, error) {
+	return s.PrepareContext(s.ctx, sql)
+}
+
+func (s shardExec) PrepareContext(ctx context.Context, sql string) (*sql.Stmt, error) {
+	exec, err := s.pick(ctx, nil, 0, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return exec.PrepareContext(ctx, sql)
+}
+
+func (s shardExec) BatchInsert(models []Model, fields uint64) (int64, []int64, error) {
+	return s.BatchInsertContext(s.ctx, models, fields)
+}
+
+// BatchInsertContext group models by shard and insert each group to it's
+// shard, the ids are in the order of models. For Shards, groups are not
+// inserted atomically, the inserted rows count is returned with error.
+func (s shardExec) BatchInsertContext(ctx context.Context, models []Model, fields uint64) (int64, []int64, error) {
+	var ids []int64
+	n, err := s.batchInsert(ctx, models, fields, func(exec Executor, group []Model, indexes []int) (int64, error) {
+		n, groupIds, err := exec.BatchInsertContext(ctx, group, fields)
+		if len(groupIds) != 0 && ids == nil {
+			ids = make([]int64, len(models))
+		}
+		for i, id := range groupIds {
+			ids[indexes[i]] = id
+		}
+		return n, err
+	})
+	if err != nil {
+		return n, nil, err
+	}
+
+	return n, ids, nil
+}
+
+func (s shardExec) BatchInsertReturning(models []Model, fields, returnFields uint64) (int64, error) {
+	return s.BatchInsertReturningContext(s.ctx, models, fields, returnFields)
+}
+
+func (s shardExec) BatchInsertReturningContext(ctx context.Context, models []Model, fields, returnFields uint64) (int64, error) {
+	return s.batchInsert(ctx, models, fields, func(exec Executor, group []Model, _ []int) (int64, error) {
+		return exec.BatchInsertReturningContext(ctx, group, fields, returnFields)
+	})
+}
+
+// batchInsert group models by shard and call insert for each group with the
+// indexes of models in group
+func (s shardExec) batchInsert(ctx context.Context, models []Model, fields uint64, insert func(Executor, []Model, []int) (int64, error)) (int64, error) {
+	var (
+		execs   []Executor
+		groups  = make(map[Executor][]int)
+		inserts int64
+	)
+	for i, model := range models {
+		exec, err := s.pick(ctx, model, fields, nil, 0)
+		if err != nil {
+			return 0, err
+		}
+		if _, has := groups[exec]; !has {
+			execs = append(execs, exec)
+		}
+		groups[exec] = append(groups[exec], i)
+	}
+
+	for _, exec := range execs {
+		indexes := groups[exec]
+		group := make([]Model, len(indexes))
+		for i, index := range indexes {
+			group[i] = models[index]
+		}
+
+		n, err := insert(exec, group, indexes)
+		inserts += n
+		if err != nil {
+			return inserts, err
+		}
+	}
+
+	return inserts, nil
+}
+
+func (s shardExec) Insert(model Model, fields uint64, resType ResultType) (int64, error) {
+	return s.InsertContext(s.ctx, model, fields, resType)
+}
+
+func (s shardExec) ArgsInsert(model Model, fields uint64, resType ResultType, args ...interface{}) (int64, error) {
+	return s.ArgsInsertContext(s.ctx, model, fields, resType, args...)
+}
+
+func (s shardExec) InsertContext(ctx context.Context, model Model, fields uint64, resType ResultType) (int64, error) {
+	exec, err := s.pick(ctx, model, fields, nil, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.InsertContext(ctx, model, fields, resType)
+}
+
+func (s shardExec) ArgsInsertContext(ctx context.Context, model Model, fields uint64, resType ResultType, args ...interface{}) (int64, error) {
+	exec, err := s.pick(ctx, model, fields, args, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.ArgsInsertContext(ctx, model, fields, resType, args...)
+}
+
+func (s shardExec) InsertReturning(model Model, fields, returnFields uint64) error {
+	return s.InsertReturningContext(s.ctx, model, fields, returnFields)
+}
+
+func (s shardExec) ArgsInsertReturning(model Model, fields, returnFields uint64, args ...interface{}) error {
+	return s.ArgsInsertReturningContext(s.ctx, model, fields, returnFields, args...)
+}
+
+func (s shardExec) InsertReturningContext(ctx context.Context, model Model, fields, returnFields uint64) error {
+	exec, err := s.pick(ctx, model, fields, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.InsertReturningContext(ctx, model, fields, returnFields)
+}
+
+func (s shardExec) ArgsInsertReturningContext(ctx context.Context, model Model, fields, returnFields uint64, args ...interface{}) error {
+	exec, err := s.pick(ctx, model, fields, args, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.ArgsInsertReturningContext(ctx, model, fields, returnFields, args...)
+}
+
+func (s shardExec) UpdateReturning(model Model, fields, whereFields, returnFields uint64) (int64, error) {
+	return s.UpdateReturningContext(s.ctx, model, fields, whereFields, returnFields)
+}
+
+func (s shardExec) ArgsUpdateReturning(model Model, fields, whereFields, returnFields uint64, args ...interface{}) (int64, error) {
+	return s.ArgsUpdateReturningContext(s.ctx, model, fields, whereFields, returnFields, args...)
+}
+
+func (s shardExec) UpdateReturningContext(ctx context.Context, model Model, fields, whereFields, returnFields uint64) (int64, error) {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.UpdateReturningContext(ctx, model, fields, whereFields, returnFields)
+}
+
+func (s shardExec) ArgsUpdateReturningContext(ctx context.Context, model Model, fields, whereFields, returnFields uint64, args ...interface{}) (int64, error) {
+	exec, err := s.pick(ctx, model, whereFields, args, NumFields(fields))
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.ArgsUpdateReturningContext(ctx, model, fields, whereFields, returnFields, args...)
+}
+
+func (s shardExec) Upsert(model Model, insertFields, conflictFields, updateFields uint64) (int64, error) {
+	return s.UpsertContext(s.ctx, model, insertFields, conflictFields, updateFields)
+}
+
+func (s shardExec) ArgsUpsert(model Model, insertFields, conflictFields, updateFields uint64, args ...interface{}) (int64, error) {
+	return s.ArgsUpsertContext(s.ctx, model, insertFields, conflictFields, updateFields, args...)
+}
+
+func (s shardExec) UpsertContext(ctx context.Context, model Model, insertFields, conflictFields, updateFields uint64) (int64, error) {
+	exec, err := s.pick(ctx, model, insertFields, nil, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.UpsertContext(ctx, model, insertFields, conflictFields, updateFields)
+}
+
+func (s shardExec) ArgsUpsertContext(ctx context.Context, model Model, insertFields, conflictFields, updateFields uint64, args ...interface{}) (int64, error) {
+	exec, err := s.pick(ctx, model, insertFields, args, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.ArgsUpsertContext(ctx, model, insertFields, conflictFields, updateFields, args...)
+}
+
+func (s shardExec) Update(model Model, fields, whereFields uint64) (int64, error) {
+	return s.UpdateContext(s.ctx, model, fields, whereFields)
+}
+
+func (s shardExec) ArgsUpdate(model Model, fields, whereFields uint64, args ...interface{}) (int64, error) {
+	return s.ArgsUpdateContext(s.ctx, model, fields, whereFields, args...)
+}
+
+func (s shardExec) UpdateContext(ctx context.Context, model Model, fields, whereFields uint64) (int64, error) {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.UpdateContext(ctx, model, fields, whereFields)
+}
+
+func (s shardExec) ArgsUpdateContext(ctx context.Context, model Model, fields, whereFields uint64, args ...interface{}) (int64, error) {
+	exec, err := s.pick(ctx, model, whereFields, args, NumFields(fields))
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.ArgsUpdateContext(ctx, model, fields, whereFields, args...)
+}
+
+func (s shardExec) Delete(model Model, whereFields uint64) (int64, error) {
+	return s.DeleteContext(s.ctx, model, whereFields)
+}
+
+func (s shardExec) ArgsDelete(model Model, whereFields uint64, args ...interface{}) (int64, error) {
+	return s.ArgsDeleteContext(s.ctx, model, whereFields, args...)
+}
+
+func (s shardExec) DeleteContext(ctx context.Context, model Model, whereFields uint64) (int64, error) {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.DeleteContext(ctx, model, whereFields)
+}
+
+func (s shardExec) ArgsDeleteContext(ctx context.Context, model Model, whereFields uint64, args ...interface{}) (int64, error) {
+	exec, err := s.pick(ctx, model, whereFields, args, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.ArgsDeleteContext(ctx, model, whereFields, args...)
+}
+
+func (s shardExec) HardDelete(model Model, whereFields uint64) (int64, error) {
+	return s.DeleteContext(s.ctx, Unscoped(model), whereFields)
+}
+
+func (s shardExec) ArgsHardDelete(model Model, whereFields uint64, args ...interface{}) (int64, error) {
+	return s.ArgsDeleteContext(s.ctx, Unscoped(model), whereFields, args...)
+}
+
+func (s shardExec) HardDeleteContext(ctx context.Context, model Model, whereFields uint64) (int64, error) {
+	return s.DeleteContext(ctx, Unscoped(model), whereFields)
+}
+
+func (s shardExec) ArgsHardDeleteContext(ctx context.Context, model Model, whereFields uint64, args ...interface{}) (int64, error) {
+	return s.ArgsDeleteContext(ctx, Unscoped(model), whereFields, args...)
+}
+
+func (s shardExec) One(model Model, fields, whereFields uint64) error {
+	return s.OneContext(s.ctx, model, fields, whereFields)
+}
+
+func (s shardExec) ArgsOne(model Model, fields, whereFields uint64, args []interface{}, ptrs ...interface{}) error {
+	return s.ArgsOneContext(s.ctx, model, fields, whereFields, args, ptrs...)
+}
+
+func (s shardExec) OneContext(ctx context.Context, model Model, fields, whereFields uint64) error {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.OneContext(ctx, model, fields, whereFields)
+}
+
+func (s shardExec) ArgsOneContext(ctx context.Context, model Model, fields, whereFields uint64, args []interface{}, ptrs ...interface{}) error {
+	exec, err := s.pick(ctx, model, whereFields, args, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.ArgsOneContext(ctx, model, fields, whereFields, args, ptrs...)
+}
+
+func (s shardExec) Limit(store Store, model Model, fields, whereFields uint64, start, count int64) error {
+	return s.LimitContext(s.ctx, store, model, fields, whereFields, start, count)
+}
+
+func (s shardExec) ArgsLimit(store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
+	return s.ArgsLimitContext(s.ctx, store, model, fields, whereFields, args...)
+}
+
+func (s shardExec) LimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, start, count int64) error {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.LimitContext(ctx, store, model, fields, whereFields, start, count)
+}
+
+func (s shardExec) ArgsLimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
+	exec, err := s.pick(ctx, model, whereFields, args, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.ArgsLimitContext(ctx, store, model, fields, whereFields, args...)
+}
+
+func (s shardExec) All(store Store, model Model, fields, whereFields uint64) error {
+	return s.AllContext(s.ctx, store, model, fields, whereFields)
+}
+
+func (s shardExec) ArgsAll(store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
+	return s.ArgsAllContext(s.ctx, store, model, fields, whereFields, args...)
+}
+
+func (s shardExec) AllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64) error {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.AllContext(ctx, store, model, fields, whereFields)
+}
+
+func (s shardExec) ArgsAllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
+	exec, err := s.pick(ctx, model, whereFields, args, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.ArgsAllContext(ctx, store, model, fields, whereFields, args...)
+}
+
+func (s shardExec) OrderOne(model Model, fields, whereFields uint64, order Order) error {
+	return s.OrderOneContext(s.ctx, model, fields, whereFields, order)
+}
+
+func (s shardExec) ArgsOrderOne(model Model, fields, whereFields uint64, order Order, args []interface{}, ptrs ...interface{}) error {
+	return s.ArgsOrderOneContext(s.ctx, model, fields, whereFields, order, args, ptrs...)
+}
+
+func (s shardExec) OrderOneContext(ctx context.Context, model Model, fields, whereFields uint64, order Order) error {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.OrderOneContext(ctx, model, fields, whereFields, order)
+}
+
+func (s shardExec) ArgsOrderOneContext(ctx context.Context, model Model, fields, whereFields uint64, order Order, args []interface{}, ptrs ...interface{}) error {
+	exec, err := s.pick(ctx, model, whereFields, args, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.ArgsOrderOneContext(ctx, model, fields, whereFields, order, args, ptrs...)
+}
+
+func (s shardExec) OrderLimit(store Store, model Model, fields, whereFields uint64, order Order, start, count int64) error {
+	return s.OrderLimitContext(s.ctx, store, model, fields, whereFields, order, start, count)
+}
+
+func (s shardExec) ArgsOrderLimit(store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error {
+	return s.ArgsOrderLimitContext(s.ctx, store, model, fields, whereFields, order, args...)
+}
+
+func (s shardExec) OrderLimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, start, count int64) error {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.OrderLimitContext(ctx, store, model, fields, whereFields, order, start, count)
+}
+
+func (s shardExec) ArgsOrderLimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error {
+	exec, err := s.pick(ctx, model, whereFields, args, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.ArgsOrderLimitContext(ctx, store, model, fields, whereFields, order, args...)
+}
+
+func (s shardExec) OrderAll(store Store, model Model, fields, whereFields uint64, order Order) error {
+	return s.OrderAllContext(s.ctx, store, model, fields, whereFields, order)
+}
+
+func (s shardExec) ArgsOrderAll(store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error {
+	return s.ArgsOrderAllContext(s.ctx, store, model, fields, whereFields, order, args...)
+}
+
+func (s shardExec) OrderAllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order) error {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.OrderAllContext(ctx, store, model, fields, whereFields, order)
+}
+
+func (s shardExec) ArgsOrderAllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error {
+	exec, err := s.pick(ctx, model, whereFields, args, 0)
+	if err != nil {
+		return err
+	}
+
+	return exec.ArgsOrderAllContext(ctx, store, model, fields, whereFields, order, args...)
+}
+
+func (s shardExec) Iter(model Model, fields, whereFields uint64, order Order) Scanner {
+	return s.IterContext(s.ctx, model, fields, whereFields, order)
+}
+
+func (s shardExec) ArgsIter(model Model, fields, whereFields uint64, order Order, args ...interface{}) Scanner {
+	return s.ArgsIterContext(s.ctx, model, fields, whereFields, order, args...)
+}
+
+func (s shardExec) IterContext(ctx context.Context, model Model, fields, whereFields uint64, order Order) Scanner {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return Scanner{Error: err}
+	}
+
+	return exec.IterContext(ctx, model, fields, whereFields, order)
+}
+
+func (s shardExec) ArgsIterContext(ctx context.Context, model Model, fields, whereFields uint64, order Order, args ...interface{}) Scanner {
+	exec, err := s.pick(ctx, model, whereFields, args, 0)
+	if err != nil {
+		return Scanner{Error: err}
+	}
+
+	return exec.ArgsIterContext(ctx, model, fields, whereFields, order, args...)
+}
+
+func (s shardExec) Page(store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int) (string, error) {
+	return s.PageContext(s.ctx, store, model, fields, whereFields, order, cursor, count)
+}
+
+func (s shardExec) ArgsPage(store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int, args ...interface{}) (string, error) {
+	return s.ArgsPageContext(s.ctx, store, model, fields, whereFields, order, cursor, count, args...)
+}
+
+func (s shardExec) PageContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int) (string, error) {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return "", err
+	}
+
+	return exec.PageContext(ctx, store, model, fields, whereFields, order, cursor, count)
+}
+
+func (s shardExec) ArgsPageContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int, args ...interface{}) (string, error) {
+	exec, err := s.pick(ctx, model, whereFields, args, 0)
+	if err != nil {
+		return "", err
+	}
+
+	return exec.ArgsPageContext(ctx, store, model, fields, whereFields, order, cursor, count, args...)
+}
+
+func (s shardExec) Count(model Model, whereFields uint64) (int64, error) {
+	return s.CountContext(s.ctx, model, whereFields)
+}
+
+func (s shardExec) ArgsCount(model Model, whereFields uint64, args ...interface{}) (int64, error) {
+	return s.ArgsCountContext(s.ctx, model, whereFields, args...)
+}
+
+func (s shardExec) CountContext(ctx context.Context, model Model, whereFields uint64) (int64, error) {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.CountContext(ctx, model, whereFields)
+}
+
+func (s shardExec) ArgsCountContext(ctx context.Context, model Model, whereFields uint64, args ...interface{}) (int64, error) {
+	exec, err := s.pick(ctx, model, whereFields, args, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.ArgsCountContext(ctx, model, whereFields, args...)
+}
+
+func (s shardExec) IncrBy(model Model, field, whereFields uint64, counts ...int) (int64, error) {
+	return s.IncrByContext(s.ctx, model, field, whereFields, counts...)
+}
+
+func (s shardExec) ArgsIncrBy(model Model, field, whereFields uint64, args ...interface{}) (int64, error) {
+	return s.ArgsIncrByContext(s.ctx, model, field, whereFields, args...)
+}
+
+func (s shardExec) IncrByContext(ctx context.Context, model Model, field, whereFields uint64, counts ...int) (int64, error) {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.IncrByContext(ctx, model, field, whereFields, counts...)
+}
+
+func (s shardExec) ArgsIncrByContext(ctx context.Context, model Model, field, whereFields uint64, args ...interface{}) (int64, error) {
+	exec, err := s.pick(ctx, model, whereFields, args, NumFields(field))
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.ArgsIncrByContext(ctx, model, field, whereFields, args...)
+}
+
+func (s shardExec) Exists(model Model, field, whereFields uint64) (bool, error) {
+	return s.ExistsContext(s.ctx, model, field, whereFields)
+}
+
+func (s shardExec) ArgsExists(model Model, field, whereFields uint64, args ...interface{}) (bool, error) {
+	return s.ArgsExistsContext(s.ctx, model, field, whereFields, args...)
+}
+
+func (s shardExec) ExistsContext(ctx context.Context, model Model, field, whereFields uint64) (bool, error) {
+	exec, err := s.pick(ctx, model, whereFields, nil, 0)
+	if err != nil {
+		return false, err
+	}
+
+	return exec.ExistsContext(ctx, model, field, whereFields)
+}
+
+func (s shardExec) ArgsExistsContext(ctx context.Context, model Model, field, whereFields uint64, args ...interface{}) (bool, error) {
+	exec, err := s.pick(ctx, model, whereFields, args, 0)
+	if err != nil {
+		return false, err
+	}
+
+	return exec.ArgsExistsContext(ctx, model, field, whereFields, args...)
+}
+
+func (s shardExec) ExecUpdate(sql string, args ...interface{}) (int64, error) {
+	return s.ExecUpdateContext(s.ctx, sql, args...)
+}
+
+func (s shardExec) Exec(sql string, resType ResultType, args ...interface{}) (int64, error) {
+	return s.ExecContext(s.ctx, sql, resType, args...)
+}
+
+func (s shardExec) ExecUpdateContext(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	exec, err := s.pick(ctx, nil, 0, args, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.ExecUpdateContext(ctx, sql, args...)
+}
+
+func (s shardExec) ExecContext(ctx context.Context, sql string, resType ResultType, args ...interface{}) (int64, error) {
+	exec, err := s.pick(ctx, nil, 0, args, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.ExecContext(ctx, sql, resType, args...)
+}
+
+func (s shardExec) ExecById(sqlid uint64, resTyp ResultType, args ...interface{}) (int64, error) {
+	return s.ExecByIdContext(s.ctx, sqlid, resTyp, args...)
+}
+
+func (s shardExec) UpdateById(sqlid uint64, args ...interface{}) (int64, error) {
+	return s.UpdateByIdContext(s.ctx, sqlid, args...)
+}
+
+func (s shardExec) QueryById(sqlid uint64, args ...interface{}) Scanner {
+	return s.QueryByIdContext(s.ctx, sqlid, args...)
+}
+
+func (s shardExec) ExecByIdContext(ctx context.Context, sqlid uint64, resTyp ResultType, args ...interface{}) (int64, error) {
+	exec, err := s.pick(ctx, nil, 0, args, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.ExecByIdContext(ctx, sqlid, resTyp, args...)
+}
+
+func (s shardExec) UpdateByIdContext(ctx context.Context, sqlid uint64, args ...interface{}) (int64, error) {
+	exec, err := s.pick(ctx, nil, 0, args, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	return exec.UpdateByIdContext(ctx, sqlid, args...)
+}
+
+func (s shardExec) QueryByIdContext(ctx context.Context, sqlid uint64, args ...interface{}) Scanner {
+	exec, err := s.pick(ctx, nil, 0, args, 0)
+	if err != nil {
+		return Scanner{Error: err}
+	}
+
+	return exec.QueryByIdContext(ctx, sqlid, args...)
+}
@@ -0,0 +1,97 @@
+package gomodel
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Unscoped disable the soft delete scope of model, the returned model can be
+// used for all Executor operations, rows marked deleted are also visible, and
+// Delete remove rows physically. It's same as model if model is not soft
+// deleted.
+func Unscoped(model Model) Model {
+	m, is := model.(opsModel)
+	if !is {
+		m = opsModel{Model: model}
+	}
+	m.unscoped = true
+
+	return m
+}
+
+// isUnscoped check whether the soft delete scope of model is disabled
+func isUnscoped(model Model) bool {
+	m, is := model.(opsModel)
+	return is && m.unscoped
+}
+
+// scopeWhere append the condition of soft delete field to where clause
+func (t *Table) scopeWhere(where string) string {
+	if t.softDelete == 0 {
+		return where
+	}
+
+	cond := t.Col(t.softDelete) + " IS NULL"
+	if where == "" {
+		return "WHERE " + cond
+	}
+	return where + " AND " + cond
+}
+
+// sqlSoftDelete create sql mark rows deleted, the where clause should be
+// scoped
+func (t *Table) sqlSoftDelete(where string) string {
+	return fmt.Sprintf("UPDATE %s SET %s=? %s",
+		t.Name,
+		t.Col(t.softDelete),
+		where)
+}
+
+// scopeKey disable the soft delete scope of key if the model is unscoped
+func (t *Table) scopeKey(model Model, key *stmtKey) {
+	key.unscoped = t.softDelete != 0 && isUnscoped(model)
+}
+
+// scopeArgs prepend the deleted time to arguments if the key is a soft delete
+func (t *Table) scopeArgs(key stmtKey, args []interface{}, clock func() time.Time) []interface{} {
+	if key.sqlType != DELETE || t.softDelete == 0 || key.unscoped {
+		return args
+	}
+
+	return append([]interface{}{clock()}, args...)
+}
+
+// HardDelete remove rows physically even if the model is soft deleted, it's
+// same as Delete(Unscoped(model), whereFields)
+func (db *DB) HardDelete(model Model, whereFields uint64) (int64, error) {
+	return db.Delete(Unscoped(model), whereFields)
+}
+
+func (db *DB) ArgsHardDelete(model Model, whereFields uint64, args ...interface{}) (int64, error) {
+	return db.ArgsDelete(Unscoped(model), whereFields, args...)
+}
+
+func (db *DB) HardDeleteContext(ctx context.Context, model Model, whereFields uint64) (int64, error) {
+	return db.DeleteContext(ctx, Unscoped(model), whereFields)
+}
+
+func (db *DB) ArgsHardDeleteContext(ctx context.Context, model Model, whereFields uint64, args ...interface{}) (int64, error) {
+	return db.ArgsDeleteContext(ctx, Unscoped(model), whereFields, args...)
+}
+
+func (tx *Tx) HardDelete(model Model, whereFields uint64) (int64, error) {
+	return tx.Delete(Unscoped(model), whereFields)
+}
+
+func (tx *Tx) ArgsHardDelete(model Model, whereFields uint64, args ...interface{}) (int64, error) {
+	return tx.ArgsDelete(Unscoped(model), whereFields, args...)
+}
+
+func (tx *Tx) HardDeleteContext(ctx context.Context, model Model, whereFields uint64) (int64, error) {
+	return tx.DeleteContext(ctx, Unscoped(model), whereFields)
+}
+
+func (tx *Tx) ArgsHardDeleteContext(ctx context.Context, model Model, whereFields uint64, args ...interface{}) (int64, error) {
+	return tx.ArgsDeleteContext(ctx, Unscoped(model), whereFields, args...)
+}
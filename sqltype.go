@@ -1,8 +1,12 @@
 package gomodel
 
+import "strconv"
+
 type SQLType uint64
 
 const (
+	// MAX_NUMFIELDS is the max fields count of models use the packed uint64
+	// statement identity, models have more fields use a wider identity
 	MAX_NUMFIELDS = 30
 )
 
@@ -17,4 +21,33 @@ const (
 	ALL
 	COUNT
 	EXISTS
+	BATCHINSERT
+	UPSERT
+	PAGE
+	// RAW is the type of sql executed directly or by sql id
+	RAW
 )
+
+var sqlTypeNames = map[SQLType]string{
+	INSERT:      "INSERT",
+	DELETE:      "DELETE",
+	UPDATE:      "UPDATE",
+	INCRBY:      "INCRBY",
+	LIMIT:       "LIMIT",
+	ONE:         "ONE",
+	ALL:         "ALL",
+	COUNT:       "COUNT",
+	EXISTS:      "EXISTS",
+	BATCHINSERT: "BATCHINSERT",
+	UPSERT:      "UPSERT",
+	PAGE:        "PAGE",
+	RAW:         "RAW",
+}
+
+func (t SQLType) String() string {
+	if name, has := sqlTypeNames[t]; has {
+		return name
+	}
+
+	return "SQLType(" + strconv.FormatUint(uint64(t>>(MAX_NUMFIELDS*2)), 10) + ")"
+}
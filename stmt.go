@@ -1,14 +1,55 @@
 package gomodel
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+)
 
 type Stmt interface {
 	Exec(...interface{}) (sql.Result, error)
 	Query(...interface{}) (*sql.Rows, error)
 	QueryRow(...interface{}) *sql.Row
+	ExecContext(context.Context, ...interface{}) (sql.Result, error)
+	QueryContext(context.Context, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, ...interface{}) *sql.Row
 	Close() error
 }
 
+// directStmt execute the sql directly in transaction without preparing, it's
+// used for statements can't or needn't be prepared
+type directStmt struct {
+	tx  *sql.Tx
+	sql string
+}
+
+func (s directStmt) Exec(args ...interface{}) (sql.Result, error) {
+	return s.ExecContext(context.Background(), args...)
+}
+
+func (s directStmt) Query(args ...interface{}) (*sql.Rows, error) {
+	return s.QueryContext(context.Background(), args...)
+}
+
+func (s directStmt) QueryRow(args ...interface{}) *sql.Row {
+	return s.QueryRowContext(context.Background(), args...)
+}
+
+func (s directStmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	return s.tx.ExecContext(ctx, s.sql, args...)
+}
+
+func (s directStmt) QueryContext(ctx context.Context, args ...interface{}) (*sql.Rows, error) {
+	return s.tx.QueryContext(ctx, s.sql, args...)
+}
+
+func (s directStmt) QueryRowContext(ctx context.Context, args ...interface{}) *sql.Row {
+	return s.tx.QueryRowContext(ctx, s.sql, args...)
+}
+
+func (s directStmt) Close() error {
+	return nil
+}
+
 type NopCloseStmt struct {
 	*sql.Stmt
 }
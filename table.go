@@ -1,9 +1,12 @@
 package gomodel
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/cosiner/gomodel/utils"
 )
@@ -19,45 +22,174 @@ type (
 	// you should not use a empty fields for limit select, that will conflict with
 	// count sql and get the wrong sql statement.
 	Table struct {
-		Name      string
-		NumFields uint64
-		cache     cache
+		Name       string
+		NumFields  uint64
+		cache      *cache
+		version    uint64 // version field for optimistic locking
+		softDelete uint64 // soft delete field, rows are marked deleted instead of removed
+		autoCreate uint64 // fields set to current time by insert
+		autoUpdate uint64 // fields set to current time by insert and update
 
 		columns   []string
-		prefix    string // Name + "."
-		colsCache map[uint64]Cols
+		prefix    string   // Name + "."
+		colsCache sync.Map // map[colsKey]Cols
 	}
 )
 
-// FieldsIdentity create signature from fields
+// FieldsIdentity create signature from fields, it's unique only if numField is
+// not greater than MAX_NUMFIELDS
 func FieldsIdentity(sqlType SQLType, numField, fields, whereFields uint64) uint64 {
 	return fields<<numField | whereFields | uint64(sqlType)
 }
 
-// Stmt get sql from cache container, if cache not exist, then create new
-func (t *Table) Stmt(exec Executor, sqlType SQLType, fields, whereFields uint64, build SQLBuilder) (Stmt, error) {
-	id := FieldsIdentity(sqlType, t.NumFields, fields, whereFields)
+type (
+	// stmtKey is the statement identity for models have more than
+	// MAX_NUMFIELDS fields or statements have extra parts like order, operators
+	stmtKey struct {
+		sqlType             SQLType
+		fields, whereFields uint64
+		order               Order
+		ops                 Ops
+		ins                 string // arity bucket of IN fields
+		rows                int    // rows count of BATCHINSERT
+		updateFields        uint64 // update fields of UPSERT, fields is insert fields, whereFields is conflict fields
+		returnFields        uint64 // RETURNING fields of INSERT, UPDATE
+		keyset              bool   // PAGE has keyset condition of cursor
+		version             uint64 // version field of UPDATE
+		unscoped            bool   // soft delete scope is disabled
+	}
 
-	sql_, stmt, err := t.cache.GetStmt(exec, id)
-	if err != nil {
-		return nil, err
+	// fieldsetKey is the statement identity for Fieldset
+	fieldsetKey struct {
+		sqlType             SQLType
+		fields, whereFields string
 	}
 
-	if stmt == nil {
-		dri := exec.Driver()
-		sql_ = build(dri, fields, whereFields)
-		sql_ = dri.Prepare(sql_)
-		sqlPrinter.Print(false, sql_)
+	// tableId is the cache id of statements of table, tables of DB share the
+	// same cache
+	tableId struct {
+		table string
+		id    interface{}
+	}
+)
+
+// identity return the cache id of statement, for models have no more than
+// MAX_NUMFIELDS fields, it's the packed uint64 identity
+func (t *Table) identity(sqlType SQLType, fields, whereFields uint64) interface{} {
+	return t.keyIdentity(stmtKey{sqlType: sqlType, fields: fields, whereFields: whereFields})
+}
+
+// keyIdentity return the packed uint64 identity if possible, otherwise the key
+// itself
+func (t *Table) keyIdentity(key stmtKey) interface{} {
+	if t.NumFields <= MAX_NUMFIELDS && key.order == (Order{}) && key.ops == (Ops{}) && key.rows == 0 && key.updateFields == 0 && key.returnFields == 0 && key.version == 0 && !key.unscoped {
+		return FieldsIdentity(key.sqlType, t.NumFields, key.fields, key.whereFields)
+	}
+
+	return key
+}
+
+// keySQL create sql for the statement key
+func (t *Table) keySQL(driver Driver, key stmtKey) string {
+	where := t.opsWhere(key.whereFields, key.ops, key.ins)
+	if !key.unscoped {
+		where = t.scopeWhere(where)
+	}
+	if key.keyset {
+		where = t.keysetWhere(where, key.order)
+	}
+	if key.version != 0 {
+		where = t.versionWhere(where, key.version)
+	}
+	if orderBy := t.OrderBy(key.order); orderBy != "" {
+		where += " " + orderBy
+	}
 
-		stmt, err = t.cache.SetStmt(exec, id, sql_)
-		if err != nil {
-			return nil, err
+	switch key.sqlType {
+	case INSERT:
+		return t.returning(driver, t.sqlInsert(t.autoCols(key.fields, t.autoFields(INSERT))), key.returnFields)
+	case UPDATE:
+		cols := t.autoCols(key.fields, t.autoFields(UPDATE))
+		if key.version != 0 {
+			return t.returning(driver, t.sqlVersionUpdate(cols, key.version, where), key.returnFields)
+		}
+		return t.returning(driver, t.sqlUpdate(cols, where), key.returnFields)
+	case DELETE:
+		if t.softDelete != 0 && !key.unscoped {
+			return t.sqlSoftDelete(where)
 		}
-	} else {
-		sqlPrinter.Print(true, sql_)
+		return t.sqlDelete(where)
+	case UPSERT:
+		updateCols := t.Cols(key.updateFields)
+		if key.updateFields != 0 {
+			updateCols = t.autoCols(key.updateFields, t.autoUpdate)
+		}
+		return t.sqlInsert(t.autoCols(key.fields, t.autoFields(UPSERT))) + " " +
+			driver.SQLUpsert(t.Cols(key.whereFields).Names(), updateCols.Names())
+	case BATCHINSERT:
+		return t.returning(driver, t.sqlBatchInsert(t.autoCols(key.fields, t.autoFields(BATCHINSERT)), key.rows), key.returnFields)
+	case INCRBY:
+		return t.sqlIncrBy(t.Cols(key.fields), where)
+	case LIMIT:
+		return t.sqlLimit(driver, t.Cols(key.fields), where)
+	case ONE:
+		return t.sqlOne(t.Cols(key.fields), where)
+	case ALL:
+		return t.sqlAll(t.Cols(key.fields), where)
+	case PAGE:
+		return t.sqlAll(t.Cols(key.fields), where) + " LIMIT ?"
+	case COUNT:
+		return t.sqlCount(where)
+	case EXISTS:
+		return t.sqlExists(t.Col(key.fields), where)
+	}
+
+	panic("unexpected sql type")
+}
+
+// returning append RETURNING clause to sql if driver support it
+func (t *Table) returning(driver Driver, sql string, returnFields uint64) string {
+	if returnFields == 0 {
+		return sql
+	}
+	if clause := driver.SQLReturning(t.Cols(returnFields).Names()); clause != "" {
+		return sql + " " + clause
 	}
 
-	return WrapStmt(STMT_NOPCLOSE, stmt, nil)
+	return sql
+}
+
+// keyStmt get cached statement for the key
+func (t *Table) keyStmt(ctx context.Context, exec Executor, key stmtKey) (Stmt, error) {
+	return t.stmt(ctx, exec, key.sqlType, t.keyIdentity(key), func(dri Driver) string {
+		return t.keySQL(dri, key)
+	})
+}
+
+func fieldsetIdentity(sqlType SQLType, fields, whereFields Fieldset) fieldsetKey {
+	return fieldsetKey{sqlType: sqlType, fields: fields.key(), whereFields: whereFields.key()}
+}
+
+// Stmt get sql from cache container, if cache not exist, then create new. The
+// returned statement is owned by cache, it needn't be closed, see StmtContext.
+func (t *Table) Stmt(exec Executor, sqlType SQLType, fields, whereFields uint64, build SQLBuilder) (Stmt, error) {
+	return t.StmtContext(context.Background(), exec, sqlType, fields, whereFields, build)
+}
+
+// StmtContext is similar to Stmt, the context is used for statement preparing
+// if it's not cached. The returned statement doesn't hold the cached statement,
+// each execution use the cached one, and prepare it again if it has been
+// evicted, Close do nothing.
+func (t *Table) StmtContext(ctx context.Context, exec Executor, sqlType SQLType, fields, whereFields uint64, build SQLBuilder) (Stmt, error) {
+	return t.cache.Share(t.stmt(ctx, exec, sqlType, t.identity(sqlType, fields, whereFields), func(dri Driver) string {
+		return build(dri, fields, whereFields)
+	}))
+}
+
+// stmt get cached statement for internal use, the returned statement hold a
+// reference to cached statement, it must be closed after used
+func (t *Table) stmt(ctx context.Context, exec Executor, sqlType SQLType, id interface{}, build func(Driver) string) (Stmt, error) {
+	return t.cache.AcquireStmt(ctx, exec, stmtInfo{table: t.Name, sqlType: sqlType}, tableId{t.Name, id}, build)
 }
 
 func (t *Table) StmtInsert(exec Executor, fields uint64) (Stmt, error) {
@@ -98,26 +230,38 @@ func (t *Table) StmtExists(exec Executor, field, whereFields uint64) (Stmt, erro
 }
 
 func (t *Table) Prepare(exec Executor, sqlType SQLType, fields, whereFields uint64, build SQLBuilder) (Stmt, error) {
-	id := FieldsIdentity(sqlType, t.NumFields, fields, whereFields)
+	return t.PrepareContext(context.Background(), exec, sqlType, fields, whereFields, build)
+}
 
-	sql_, stmt, err := t.cache.PrepareSQL(exec, id)
+// PrepareContext is similar to Prepare, the context is used for statement preparing
+func (t *Table) PrepareContext(ctx context.Context, exec Executor, sqlType SQLType, fields, whereFields uint64, build SQLBuilder) (Stmt, error) {
+	return t.prepare(ctx, exec, sqlType, t.identity(sqlType, fields, whereFields), func(dri Driver) string {
+		return build(dri, fields, whereFields)
+	})
+}
+
+func (t *Table) prepare(ctx context.Context, exec Executor, sqlType SQLType, id interface{}, build func(Driver) string) (Stmt, error) {
+	id = tableId{t.Name, id}
+	sql_, stmt, err := t.cache.PrepareSQL(ctx, exec, id)
 	if err != nil {
 		return nil, err
 	}
 
-	if stmt == nil {
+	// the sql is cached if the statement is prepared from cache
+	cached := stmt != nil
+	if !cached {
 		dri := exec.Driver()
-		sql_ = build(dri, fields, whereFields)
-		sql_ = dri.Prepare(sql_)
+		sql_ = dri.Prepare(build(dri))
 
 		t.cache.SetSQL(id, sql_)
-		sqlPrinter.Print(false, sql_)
-		stmt, err = exec.Prepare(sql_)
-	} else {
-		sqlPrinter.Print(true, sql_)
+		stmt, err = exec.PrepareContext(ctx, sql_)
+	}
+	sqlPrinter.Print(cached, sql_)
+	if err != nil {
+		return nil, err
 	}
 
-	return WrapStmt(STMT_CLOSEABLE, stmt, err)
+	return withHook(exec, stmt, stmtInfo{sql: sql_, table: t.Name, sqlType: sqlType, cached: cached}), nil
 }
 
 func (t *Table) PrepareInsert(exec Executor, fields uint64) (Stmt, error) {
@@ -156,84 +300,132 @@ func (t *Table) PrepareExists(exec Executor, field, whereFields uint64) (Stmt, e
 	return t.Prepare(exec, EXISTS, field, whereFields, t.SQLExists)
 }
 
-// InsertSQL create insert sql for given fields
+// InsertSQL create insert sql for given fields, auto timestamp fields not in
+// fields are appended, the arguments of them follow the arguments of fields
 func (t *Table) SQLInsert(_ Driver, fields, _ uint64) string {
-	cols := t.Cols(fields)
+	return t.sqlInsert(t.autoCols(fields, t.autoFields(INSERT)))
+}
+
+// UpdateSQL create update sql for given fields, auto update fields not in
+// fields are appended, the arguments of them follow the arguments of fields
+func (t *Table) SQLUpdate(_ Driver, fields, whereFields uint64) string {
+	return t.sqlUpdate(t.autoCols(fields, t.autoFields(UPDATE)), t.Where(whereFields))
+}
+
+// DeleteSQL create delete sql for given fields, if the table is soft deleted,
+// it's a update sql set the soft delete field, the deleted time is the first
+// argument
+func (t *Table) SQLDelete(_ Driver, _, whereFields uint64) string {
+	if t.softDelete != 0 {
+		return t.sqlSoftDelete(t.Where(whereFields))
+	}
+	return t.sqlDelete(t.Where(whereFields))
+}
+
+// LimitSQL create select sql for given fields
+func (t *Table) SQLLimit(driver Driver, fields, whereFields uint64) string {
+	return t.sqlLimit(driver, t.Cols(fields), t.Where(whereFields))
+}
+
+// LimitSQL create select sql for given fields
+func (t *Table) SQLOne(_ Driver, fields, whereFields uint64) string {
+	return t.sqlOne(t.Cols(fields), t.Where(whereFields))
+}
+
+// AllSQL create select sql for given fields
+func (t *Table) SQLAll(_ Driver, fields, whereFields uint64) string {
+	return t.sqlAll(t.Cols(fields), t.Where(whereFields))
+}
+
+// SQLForCount create select count sql
+func (t *Table) SQLCount(_ Driver, _, whereFields uint64) string {
+	return t.sqlCount(t.Where(whereFields))
+}
 
+// SQLIncrBy create sql for increase/decrease field value
+func (t *Table) SQLIncrBy(_ Driver, fields, whereFields uint64) string {
+	return t.sqlIncrBy(t.Cols(fields), t.Where(whereFields))
+}
+
+// SQLExists create sql for checking whether model exists
+func (t *Table) SQLExists(_ Driver, field, whereFields uint64) string {
+	return t.sqlExists(t.Col(field), t.Where(whereFields))
+}
+
+func (t *Table) sqlInsert(cols Cols) string {
 	return fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)",
 		t.Name,
 		cols.String(),
 		cols.OnlyParam())
 }
 
-// UpdateSQL create update sql for given fields
-func (t *Table) SQLUpdate(_ Driver, fields, whereFields uint64) string {
-	return fmt.Sprintf("UPDATE %s SET %s %s",
+func (t *Table) sqlBatchInsert(cols Cols, rows int) string {
+	values := "(" + cols.OnlyParam() + ")"
+
+	return fmt.Sprintf("INSERT INTO %s(%s) VALUES%s",
 		t.Name,
-		t.Cols(fields).Paramed(),
-		t.Where(whereFields))
+		cols.String(),
+		strings.Repeat(values+",", rows-1)+values)
 }
 
-// DeleteSQL create delete sql for given fields
-func (t *Table) SQLDelete(_ Driver, _, whereFields uint64) string {
-	return fmt.Sprintf("DELETE FROM %s %s", t.Name, t.Where(whereFields))
+func (t *Table) sqlUpdate(cols Cols, where string) string {
+	return fmt.Sprintf("UPDATE %s SET %s %s",
+		t.Name,
+		cols.Paramed(),
+		where)
 }
 
-// LimitSQL create select sql for given fields
-func (t *Table) SQLLimit(driver Driver, fields, whereFields uint64) string {
+func (t *Table) sqlDelete(where string) string {
+	return fmt.Sprintf("DELETE FROM %s %s", t.Name, where)
+}
 
+func (t *Table) sqlLimit(driver Driver, cols Cols, where string) string {
 	return fmt.Sprintf("SELECT %s FROM %s %s "+driver.SQLLimit(),
-		t.Cols(fields),
+		cols,
 		t.Name,
-		t.Where(whereFields))
+		where)
 }
 
-// LimitSQL create select sql for given fields
-func (t *Table) SQLOne(_ Driver, fields, whereFields uint64) string {
+func (t *Table) sqlOne(cols Cols, where string) string {
 	return fmt.Sprintf("SELECT %s FROM %s %s LIMIT 1",
-		t.Cols(fields),
+		cols,
 		t.Name,
-		t.Where(whereFields))
+		where)
 }
 
-// AllSQL create select sql for given fields
-func (t *Table) SQLAll(_ Driver, fields, whereFields uint64) string {
+func (t *Table) sqlAll(cols Cols, where string) string {
 	return fmt.Sprintf("SELECT %s FROM %s %s",
-		t.Cols(fields),
+		cols,
 		t.Name,
-		t.Where(whereFields))
+		where)
 }
 
-// SQLForCount create select count sql
-func (t *Table) SQLCount(_ Driver, _, whereFields uint64) string {
+func (t *Table) sqlCount(where string) string {
 	return fmt.Sprintf("SELECT COUNT(*) FROM %s %s",
 		t.Name,
-		t.Where(whereFields))
+		where)
 }
 
-// SQLIncrBy create sql for increase/decrease field value
-func (t *Table) SQLIncrBy(_ Driver, fields, whereFields uint64) string {
+func (t *Table) sqlIncrBy(cols Cols, where string) string {
 	return fmt.Sprintf("UPDATE %s SET %s %s",
 		t.Name,
-		t.Cols(fields).IncrParamed(),
-		t.Where(whereFields),
-	)
+		cols.IncrParamed(),
+		where)
 }
 
-// SQLExists create sql for checking whether model exists
-func (t *Table) SQLExists(_ Driver, field, whereFields uint64) string {
-	col := t.Col(field)
-
+func (t *Table) sqlExists(col, where string) string {
 	return fmt.Sprintf("SELECT EXISTS(SELECT %s FROM %s %s) FROM DUAL",
 		col,
 		t.Name,
-		t.Where(whereFields),
-	)
+		where)
 }
 
 // Where create where clause for given fields, the 'WHERE' word is included
 func (t *Table) Where(fields uint64) string {
-	cols := t.Cols(fields)
+	return t.scopeWhere(where(t.Cols(fields)))
+}
+
+func where(cols Cols) string {
 	if cols.Length() == 0 {
 		return ""
 	}
@@ -274,19 +466,84 @@ func (t *Table) TabCol(field uint64) string {
 	return t.TabCols(field).String()
 }
 
+// FieldsetCols is similar to Cols, but for Fieldset
+func (t *Table) FieldsetCols(fields Fieldset) Cols {
+	key := colsKey{typ: _FIELDSET_COLS, fieldset: fields.key()}
+	if cols, has := t.colsCache.Load(key); has {
+		return cols.(Cols)
+	}
+
+	cols, _ := t.colsCache.LoadOrStore(key, t.colsOf(fields, ""))
+	return cols.(Cols)
+}
+
+// FieldsetWhere is similar to Where, but for Fieldset
+func (t *Table) FieldsetWhere(fields Fieldset) string {
+	return where(t.FieldsetCols(fields))
+}
+
+// FieldsetSQL create sql of given type for Fieldset, only INSERT, UPDATE,
+// DELETE, ONE, ALL, COUNT are supported
+func (t *Table) FieldsetSQL(_ Driver, sqlType SQLType, fields, whereFields Fieldset) string {
+	switch sqlType {
+	case INSERT:
+		return t.sqlInsert(t.FieldsetCols(fields))
+	case UPDATE:
+		return t.sqlUpdate(t.FieldsetCols(fields), t.FieldsetWhere(whereFields))
+	case DELETE:
+		return t.sqlDelete(t.FieldsetWhere(whereFields))
+	case ONE:
+		return t.sqlOne(t.FieldsetCols(fields), t.FieldsetWhere(whereFields))
+	case ALL:
+		return t.sqlAll(t.FieldsetCols(fields), t.FieldsetWhere(whereFields))
+	case COUNT:
+		return t.sqlCount(t.FieldsetWhere(whereFields))
+	}
+
+	panic("unsupported sql type for fieldset")
+}
+
+// FieldsetStmt is similar to StmtContext, but for Fieldset and the sql is
+// created by FieldsetSQL
+func (t *Table) FieldsetStmt(ctx context.Context, exec Executor, sqlType SQLType, fields, whereFields Fieldset) (Stmt, error) {
+	return t.cache.Share(t.fieldsetStmt(ctx, exec, sqlType, fields, whereFields))
+}
+
+func (t *Table) fieldsetStmt(ctx context.Context, exec Executor, sqlType SQLType, fields, whereFields Fieldset) (Stmt, error) {
+	return t.stmt(ctx, exec, sqlType, fieldsetIdentity(sqlType, fields, whereFields), func(dri Driver) string {
+		return t.FieldsetSQL(dri, sqlType, fields, whereFields)
+	})
+}
+
+// FieldsetPrepare is similar to PrepareContext, but for Fieldset and the sql is
+// created by FieldsetSQL
+func (t *Table) FieldsetPrepare(ctx context.Context, exec Executor, sqlType SQLType, fields, whereFields Fieldset) (Stmt, error) {
+	return t.prepare(ctx, exec, sqlType, fieldsetIdentity(sqlType, fields, whereFields), func(dri Driver) string {
+		return t.FieldsetSQL(dri, sqlType, fields, whereFields)
+	})
+}
+
 const (
-	_COLS = iota + 1<<(2*MAX_NUMFIELDS)
+	_COLS = iota + 1
 	_TAB_COLS
+	_FIELDSET_COLS
 )
 
-func (t *Table) colsByType(typ, fields uint64) Cols {
-	cols := t.colsCache[typ|fields]
-	if cols == nil {
-		cols = t.cols(fields, "")
-		t.colsCache[typ|fields] = cols
+// colsKey is the key of columns cache
+type colsKey struct {
+	typ      int
+	fields   uint64
+	fieldset string
+}
+
+func (t *Table) colsByType(typ int, fields uint64) Cols {
+	key := colsKey{typ: typ, fields: fields}
+	if cols, has := t.colsCache.Load(key); has {
+		return cols.(Cols)
 	}
 
-	return cols
+	cols, _ := t.colsCache.LoadOrStore(key, t.cols(fields, ""))
+	return cols.(Cols)
 }
 
 // cols get fields names, each field prepend a prefix string
@@ -294,21 +551,25 @@ func (t *Table) colsByType(typ, fields uint64) Cols {
 // if fields count is 1, type singleCols was returned
 // otherwise, type cols was returned
 func (t *Table) cols(fields uint64, prefix string) Cols {
+	return t.colsOf(Fieldset{fields}, prefix)
+}
+
+func (t *Table) colsOf(fields Fieldset, prefix string) Cols {
 	fieldNames := t.columns
-	if colCount := NumFields(fields); colCount > 1 {
+	if colCount := fields.NumFields(); colCount > 1 {
 		names := make([]string, colCount)
 		var index int
-		for i, l := uint64(0), uint64(len(fieldNames)); i < l; i++ {
-			if (1<<i)&fields != 0 {
+		for i, l := 0, len(fieldNames); i < l; i++ {
+			if fields.Has(i) {
 				names[index] = prefix + fieldNames[i]
 				index++
 			}
 		}
 
-		return &MultipleCols{Cols: names}
+		return newMultipleCols(names)
 	} else if colCount == 1 {
-		for i, l := uint64(0), uint64(len(fieldNames)); i < l; i++ {
-			if (1<<i)&fields != 0 {
+		for i, l := 0, len(fieldNames); i < l; i++ {
+			if fields.Has(i) {
 				return SingleCol(prefix + fieldNames[i])
 			}
 		}
@@ -319,15 +580,40 @@ func (t *Table) cols(fields uint64, prefix string) Cols {
 
 // parseModel will first use field tag as column name, the tag key is 'column',
 // if no tag specified, use field name's camel_case, disable a field or model
-// by set '-' as field tag value
-func parseModel(v Model, db *DB) *Table {
-	var nocache bool
+// by set '-' as field tag value. Tags version, softdelete, autocreate and
+// autoupdate are not supported for models have more than 64 fields.
+func parseModel(v Model, db *DB) (*Table, error) {
+	var (
+		nocache    bool
+		version    uint64
+		softDelete uint64
+		autoCreate uint64
+		autoUpdate uint64
+	)
 	if nc, is := v.(Nocacher); is {
 		nocache = nc.Nocache()
 	}
+	if vr, is := v.(Versioner); is {
+		version = vr.VersionField()
+	}
+	if sd, is := v.(SoftDeleter); is {
+		softDelete = sd.SoftDeleteField()
+	}
+	if ts, is := v.(Timestamper); is {
+		autoCreate, autoUpdate = ts.AutoCreateFields(), ts.AutoUpdateFields()
+	}
 
 	if c, is := v.(Columner); is {
-		return newTable(v.Table(), c.Columns(), nocache)
+		cols := c.Columns()
+		if len(cols) > 64 && version|softDelete|autoCreate|autoUpdate != 0 {
+			return nil, errWideFields(v.Table(), len(cols), "version, soft delete and auto timestamp fields")
+		}
+
+		t := newTable(v.Table(), cols, nocache)
+		t.version = version
+		t.softDelete = softDelete
+		t.autoCreate, t.autoUpdate = autoCreate, autoUpdate
+		return t, nil
 	}
 	typ := reflect.TypeOf(v)
 	if typ.Kind() == reflect.Ptr {
@@ -335,7 +621,10 @@ func parseModel(v Model, db *DB) *Table {
 	}
 	num := typ.NumField()
 
-	cols := make([]string, 0)
+	var (
+		cols   = make([]string, 0)
+		tagged []string // fields have tags use the bit of field
+	)
 
 	for i := 0; i < num; i++ {
 		field := typ.Field(i)
@@ -359,25 +648,52 @@ func parseModel(v Model, db *DB) *Table {
 			if colTag != "" {
 				col = colTag
 			}
+			bit := uint64(1) << uint(len(cols)) // 0 for fields after the 64th
+			if b, _ := strconv.ParseBool(field.Tag.Get("version")); b {
+				version = bit
+				tagged = append(tagged, field.Name)
+			}
+			if b, _ := strconv.ParseBool(field.Tag.Get("softdelete")); b {
+				softDelete = bit
+				tagged = append(tagged, field.Name)
+			}
+			if b, _ := strconv.ParseBool(field.Tag.Get("autocreate")); b {
+				autoCreate |= bit
+				tagged = append(tagged, field.Name)
+			}
+			if b, _ := strconv.ParseBool(field.Tag.Get("autoupdate")); b {
+				autoUpdate |= bit
+				tagged = append(tagged, field.Name)
+			}
 
 			cols = append(cols, col)
 		}
 	}
 
-	return newTable(
+	if len(cols) > 64 && (len(tagged) > 0 || version|softDelete|autoCreate|autoUpdate != 0) {
+		return nil, errWideFields(v.Table(), len(cols), "tags of fields "+strings.Join(tagged, ","))
+	}
+
+	t := newTable(
 		v.Table(),
 		utils.TruncCapToLen(cols),
 		nocache,
 	)
+	t.version = version
+	t.softDelete = softDelete
+	t.autoCreate, t.autoUpdate = autoCreate, autoUpdate
+	return t, nil
+}
+
+// errWideFields create the error for fields can't be used by models have more
+// than 64 fields
+func errWideFields(table string, numFields int, fields string) error {
+	return fmt.Errorf("%s are not supported for table %s, it has %d fields, more than 64", fields, table, numFields)
 }
 
 // newTable create Table for a Model with the table name and columns, if nocache,
 // it will not allocate cache memory
 func newTable(table string, cols []string, nocache bool) *Table {
-	if len(cols) > MAX_NUMFIELDS {
-		panic(fmt.Sprint("can't register model with fields count over ", MAX_NUMFIELDS))
-	}
-
 	t := &Table{
 		NumFields: uint64(len(cols)),
 		Name:      table,
@@ -386,8 +702,7 @@ func newTable(table string, cols []string, nocache bool) *Table {
 	if !nocache {
 		t.prefix = table + "."
 		t.columns = cols
-		t.cache = newCache()
-		t.colsCache = make(map[uint64]Cols)
+		t.cache = newCache(0)
 	}
 
 	return t
@@ -0,0 +1,105 @@
+package gomodel
+
+import (
+	"database/sql"
+	"time"
+)
+
+// now return current time of the clock of DB
+func (db *DB) now() time.Time {
+	if db.Clock != nil {
+		return db.Clock()
+	}
+
+	return time.Now()
+}
+
+// autoFields return the auto timestamp fields of table for the sql type
+func (t *Table) autoFields(sqlType SQLType) uint64 {
+	switch sqlType {
+	case INSERT, UPSERT, BATCHINSERT:
+		return t.autoCreate | t.autoUpdate
+	case UPDATE:
+		return t.autoUpdate
+	}
+
+	return 0
+}
+
+// autoCols return columns of fields, auto timestamp fields not in fields are
+// appended
+func (t *Table) autoCols(fields, auto uint64) Cols {
+	cols := t.Cols(fields)
+	if auto &^= fields; auto == 0 {
+		return cols
+	}
+
+	names := append(append([]string{}, cols.Names()...), t.Cols(auto).Names()...)
+	return newMultipleCols(names)
+}
+
+// autoArgs insert current time to arguments for auto timestamp fields not in
+// fields of the key, they are placed after the arguments of fields, for batch
+// insert, they are placed after the arguments of each row
+func (t *Table) autoArgs(key stmtKey, args []interface{}, clock func() time.Time) []interface{} {
+	auto := t.autoFields(key.sqlType) &^ key.fields
+	if auto == 0 {
+		return args
+	}
+
+	rows := 1
+	if key.sqlType == BATCHINSERT {
+		rows = key.rows
+	}
+	numFields, numAuto := NumFields(key.fields), NumFields(auto)
+	now := clock()
+	res := make([]interface{}, 0, len(args)+rows*numAuto)
+	for ; rows > 0; rows-- {
+		n := numFields
+		if n > len(args) {
+			n = len(args)
+		}
+		res = append(res, args[:n]...)
+		args = args[n:]
+		for i := numAuto; i > 0; i-- {
+			res = append(res, now)
+		}
+	}
+
+	return append(res, args...)
+}
+
+// stamp set auto timestamp fields of model to current time, fields set
+// successfully are added to fields
+func (db *DB) stamp(model Model, sqlType SQLType, fields uint64) uint64 {
+	auto := db.Table(model).autoFields(sqlType)
+	if auto == 0 {
+		return fields
+	}
+
+	now := db.now()
+	for field := uint64(1); field != 0 && field <= auto; field <<= 1 {
+		if auto&field != 0 && setTime(FieldPtrs(model, field)[0], now) {
+			fields |= field
+		}
+	}
+
+	return fields
+}
+
+// setTime store the time to the pointer of field, only *time.Time,
+// **time.Time and sql.Scanner are supported
+func setTime(ptr interface{}, now time.Time) bool {
+	switch p := ptr.(type) {
+	case *time.Time:
+		*p = now
+	case **time.Time:
+		*p = &now
+	case sql.Scanner:
+		return p.Scan(now) == nil
+	default:
+		return false
+	}
+
+	return true
+}
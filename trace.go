@@ -0,0 +1,84 @@
+package gomodel
+
+import "context"
+
+const (
+	// SPAN_PREFIX is the prefix of span names, statement spans are named
+	// SPAN_PREFIX + SQLType, transaction spans are named SPAN_TX
+	SPAN_PREFIX = "gomodel."
+	SPAN_TX     = SPAN_PREFIX + "Tx"
+)
+
+type (
+	// SpanInfo describe the operation of a span
+	SpanInfo struct {
+		Name  string
+		Tx    bool    // span of transaction, other fields except Name are empty
+		Table string  // empty for RAW
+		Type  SQLType // type of sql, RAW for sql executed directly or by sql id
+		SQLId uint64  // sql id of ExecById, QueryById, only valid if ById
+		ById  bool
+		SQL   string
+	}
+
+	// Span is started by Tracer, and ended after operation finished
+	Span interface {
+		End(err error)
+	}
+
+	// Tracer start spans for executions of statements and transactions, the
+	// span should be propagated through the returned context
+	Tracer interface {
+		Start(ctx context.Context, info SpanInfo) (context.Context, Span)
+		// Inherit return ctx carry the span of parent, it's used to make
+		// executions in transaction children of the transaction span
+		Inherit(ctx, parent context.Context) context.Context
+	}
+)
+
+func (i stmtInfo) span() SpanInfo {
+	return SpanInfo{
+		Name:  SPAN_PREFIX + i.sqlType.String(),
+		Table: i.table,
+		Type:  i.sqlType,
+		SQLId: i.sqlid,
+		ById:  i.byId,
+		SQL:   i.sql,
+	}
+}
+
+// startTx start span for transaction, the span is ended when the transaction
+// is committed or rollbacked
+func (db *DB) startTx(ctx context.Context) (context.Context, Span) {
+	if db.Tracer == nil {
+		return ctx, nil
+	}
+
+	return db.Tracer.Start(ctx, SpanInfo{Name: SPAN_TX, Tx: true})
+}
+
+// endTx end the span of transaction once
+func (tx *Tx) endTx(err error) {
+	tx.mu.Lock()
+	span := tx.span
+	tx.span = nil
+	tx.mu.Unlock()
+
+	if span != nil {
+		span.End(err)
+	}
+}
+
+// Commit commit the transaction and end the span of it
+func (tx *Tx) Commit() error {
+	err := tx.Tx.Commit()
+	tx.endTx(err)
+	return err
+}
+
+// Rollback rollback the transaction and end the span of it
+func (tx *Tx) Rollback() error {
+	err := tx.Tx.Rollback()
+	tx.endTx(err)
+	return err
+}
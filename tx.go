@@ -1,23 +1,61 @@
 package gomodel
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
 
 type (
 	Tx struct {
 		*sql.Tx
 		db        *DB
+		ctx       context.Context
+		opts      TxOptions
 		isSuccess bool
+		nested    int  // depth of nested TxDo
+		span      Span // span of transaction, ended after committed or rollbacked
+
+		mu    sync.Mutex
+		stmts map[*sql.Stmt]*sql.Stmt // statements of DB bound to transaction
 	}
+
+	// TxOptions is the isolation level and read-only flag of transaction
+	TxOptions = sql.TxOptions
 )
 
-func newTx(tx *sql.Tx, db *DB) *Tx {
+// ErrTxIncompatible means the transaction can't be reused for the options
+var ErrTxIncompatible = errors.New("transaction is incompatible with the options")
+
+func newTx(ctx context.Context, tx *sql.Tx, db *DB, opts TxOptions) *Tx {
 	return &Tx{
 		Tx:        tx,
 		db:        db,
+		ctx:       ctx,
+		opts:      opts,
 		isSuccess: true,
 	}
 }
 
+// Options return the options used to start the transaction
+func (tx *Tx) Options() TxOptions {
+	return tx.opts
+}
+
+// Compatible check whether the transaction can be reused for operations
+// require the options, the isolation level of transaction must not be lower,
+// and a read-only transaction can only be used for read-only operations
+func (tx *Tx) Compatible(opts TxOptions) bool {
+	if tx.opts.ReadOnly && !opts.ReadOnly {
+		return false
+	}
+
+	return opts.Isolation == sql.LevelDefault || tx.opts.Isolation >= opts.Isolation
+}
+
 func (tx *Tx) Driver() Driver {
 	return tx.db.Driver()
 }
@@ -26,95 +64,487 @@ func (tx *Tx) Table(model Model) *Table {
 	return tx.db.Table(model)
 }
 
+// stmt get the statement of DB for the key and bind it to transaction,
+// operators attached to model are used, arguments of IN fields are expanded
+func (tx *Tx) stmt(ctx context.Context, model Model, key stmtKey, args []interface{}) (Stmt, []interface{}, error) {
+	t := tx.Table(model)
+	t.scopeKey(model, &key)
+	_, key.ops = unwrapOps(model)
+	args, err := key.expandIn(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	args = t.scopeArgs(key, args, tx.db.now)
+	args = t.autoArgs(key, args, tx.db.now)
+
+	stmt, err := t.keyStmt(ctx, tx.db, key)
+	stmt, err = tx.bind(ctx, stmt, err)
+	return stmt, args, err
+}
+
+// bind bind the cached statement of DB to transaction, the bound statement is
+// reused in the transaction, and closed when transaction is committed or
+// rollbacked, so it don't need to be closed. The reference to cached statement
+// of DB is released after bound.
+func (tx *Tx) bind(ctx context.Context, stmt Stmt, err error) (Stmt, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	stmt, info := unwrapHook(stmt)
+	s := stmt.(*cachedStmt).Stmt
+	tx.mu.Lock()
+	txStmt, has := tx.stmts[s]
+	if !has {
+		txStmt = tx.Tx.StmtContext(ctx, s)
+		if tx.stmts == nil {
+			tx.stmts = make(map[*sql.Stmt]*sql.Stmt)
+		}
+		tx.stmts[s] = txStmt
+	}
+	tx.mu.Unlock()
+
+	return withHook(tx, NopCloseStmt{txStmt}, info), nil
+}
+
+// Context return the context used to start the transaction, it's used for all
+// operations without context
+func (tx *Tx) Context() context.Context {
+	if tx.ctx == nil {
+		return context.Background()
+	}
+
+	return tx.ctx
+}
+
 func (tx *Tx) Insert(model Model, fields uint64, resType ResultType) (int64, error) {
-	return tx.ArgsInsert(model, fields, resType, FieldVals(model, fields)...)
+	return tx.InsertContext(tx.Context(), model, fields, resType)
 }
 
 func (tx *Tx) ArgsInsert(model Model, fields uint64, resType ResultType, args ...interface{}) (int64, error) {
-	stmt, err := tx.Table(model).PrepareInsert(tx, fields)
+	return tx.ArgsInsertContext(tx.Context(), model, fields, resType, args...)
+}
+
+func (tx *Tx) InsertContext(ctx context.Context, model Model, fields uint64, resType ResultType) (int64, error) {
+	if err := beforeHook(ctx, tx, model, INSERT); err != nil {
+		return 0, err
+	}
 
-	return CloseExec(stmt, err, resType, args...)
+	fields = tx.db.stamp(model, INSERT, fields)
+	return tx.insert(ctx, model, fields, resType, FieldVals(model, fields))
 }
 
-func (tx *Tx) Update(model Model, fields, whereFields uint64) (int64, error) {
-	c1, c2 := NumFields(fields), NumFields(whereFields)
-	args := make([]interface{}, c1+c2)
-	model.Vals(fields, args)
-	model.Vals(whereFields, args[c1:])
+func (tx *Tx) ArgsInsertContext(ctx context.Context, model Model, fields uint64, resType ResultType, args ...interface{}) (int64, error) {
+	if err := beforeHook(ctx, tx, model, INSERT); err != nil {
+		return 0, err
+	}
+
+	return tx.insert(ctx, model, fields, resType, args)
+}
+
+func (tx *Tx) insert(ctx context.Context, model Model, fields uint64, resType ResultType, args []interface{}) (int64, error) {
+	stmt, args, err := tx.stmt(ctx, model, stmtKey{sqlType: INSERT, fields: fields}, args)
+	n, err := CloseExecContext(ctx, stmt, err, resType, args...)
+
+	return afterHook(ctx, tx, model, INSERT, n, err)
+}
+
+func (tx *Tx) InsertReturning(model Model, fields, returnFields uint64) error {
+	return tx.InsertReturningContext(tx.Context(), model, fields, returnFields)
+}
+
+func (tx *Tx) ArgsInsertReturning(model Model, fields, returnFields uint64, args ...interface{}) error {
+	return tx.ArgsInsertReturningContext(tx.Context(), model, fields, returnFields, args...)
+}
+
+func (tx *Tx) InsertReturningContext(ctx context.Context, model Model, fields, returnFields uint64) error {
+	if err := beforeHook(ctx, tx, model, INSERT); err != nil {
+		return err
+	}
+
+	fields = tx.db.stamp(model, INSERT, fields)
+	return tx.insertReturning(ctx, model, fields, returnFields, FieldVals(model, fields))
+}
+
+func (tx *Tx) ArgsInsertReturningContext(ctx context.Context, model Model, fields, returnFields uint64, args ...interface{}) error {
+	if err := beforeHook(ctx, tx, model, INSERT); err != nil {
+		return err
+	}
+
+	return tx.insertReturning(ctx, model, fields, returnFields, args)
+}
+
+func (tx *Tx) insertReturning(ctx context.Context, model Model, fields, returnFields uint64, args []interface{}) error {
+	stmt, args, err := tx.stmt(ctx, model, stmtKey{sqlType: INSERT, fields: fields, returnFields: returnFields}, args)
+	n, err := execReturning(ctx, tx, stmt, err, INSERT, model, returnFields, args)
+	_, err = afterHook(ctx, tx, model, INSERT, n, err)
+
+	return err
+}
+
+func (tx *Tx) UpdateReturning(model Model, fields, whereFields, returnFields uint64) (int64, error) {
+	return tx.UpdateReturningContext(tx.Context(), model, fields, whereFields, returnFields)
+}
+
+func (tx *Tx) ArgsUpdateReturning(model Model, fields, whereFields, returnFields uint64, args ...interface{}) (int64, error) {
+	return tx.ArgsUpdateReturningContext(tx.Context(), model, fields, whereFields, returnFields, args...)
+}
+
+func (tx *Tx) UpdateReturningContext(ctx context.Context, model Model, fields, whereFields, returnFields uint64) (int64, error) {
+	if err := beforeHook(ctx, tx, model, UPDATE); err != nil {
+		return 0, err
+	}
+
+	fields = tx.db.stamp(model, UPDATE, fields)
+	version := tx.Table(model).version
+	return tx.updateReturning(ctx, model, fields, whereFields, returnFields, versionArgs(model, version, fields, whereFields), returnFields&version == 0)
+}
+
+func (tx *Tx) ArgsUpdateReturningContext(ctx context.Context, model Model, fields, whereFields, returnFields uint64, args ...interface{}) (int64, error) {
+	if err := beforeHook(ctx, tx, model, UPDATE); err != nil {
+		return 0, err
+	}
+
+	return tx.updateReturning(ctx, model, fields, whereFields, returnFields, args, false)
+}
 
-	return tx.ArgsUpdate(model, fields, whereFields, args...)
+func (tx *Tx) updateReturning(ctx context.Context, model Model, fields, whereFields, returnFields uint64, args []interface{}, incr bool) (int64, error) {
+	t := tx.Table(model)
+	key := versionKey(t, fields, whereFields)
+	key.returnFields = returnFields
+	stmt, args, err := tx.stmt(ctx, model, key, args)
+	n, err := execReturning(ctx, tx, stmt, err, UPDATE, model, returnFields, args)
+	n, err = versionResult(model, t.version, incr, n, err)
+
+	return afterHook(ctx, tx, model, UPDATE, n, err)
+}
+
+func (tx *Tx) Upsert(model Model, insertFields, conflictFields, updateFields uint64) (int64, error) {
+	return tx.UpsertContext(tx.Context(), model, insertFields, conflictFields, updateFields)
+}
+
+func (tx *Tx) ArgsUpsert(model Model, insertFields, conflictFields, updateFields uint64, args ...interface{}) (int64, error) {
+	return tx.ArgsUpsertContext(tx.Context(), model, insertFields, conflictFields, updateFields, args...)
+}
+
+func (tx *Tx) UpsertContext(ctx context.Context, model Model, insertFields, conflictFields, updateFields uint64) (int64, error) {
+	if err := beforeHook(ctx, tx, model, INSERT); err != nil {
+		return 0, err
+	}
+
+	insertFields = tx.db.stamp(model, UPSERT, insertFields)
+	return tx.upsert(ctx, model, insertFields, conflictFields, updateFields, FieldVals(model, insertFields))
+}
+
+func (tx *Tx) ArgsUpsertContext(ctx context.Context, model Model, insertFields, conflictFields, updateFields uint64, args ...interface{}) (int64, error) {
+	if err := beforeHook(ctx, tx, model, INSERT); err != nil {
+		return 0, err
+	}
+
+	return tx.upsert(ctx, model, insertFields, conflictFields, updateFields, args)
+}
+
+func (tx *Tx) upsert(ctx context.Context, model Model, insertFields, conflictFields, updateFields uint64, args []interface{}) (int64, error) {
+	stmt, args, err := tx.stmt(ctx, model, stmtKey{sqlType: UPSERT, fields: insertFields, whereFields: conflictFields, updateFields: updateFields}, args)
+	n, err := CloseUpdateContext(ctx, stmt, err, args...)
+
+	return afterHook(ctx, tx, model, INSERT, n, err)
+}
+
+func (tx *Tx) BatchInsert(models []Model, fields uint64) (int64, []int64, error) {
+	return tx.BatchInsertContext(tx.Context(), models, fields)
+}
+
+func (tx *Tx) BatchInsertContext(ctx context.Context, models []Model, fields uint64) (int64, []int64, error) {
+	return tx.batchInsert(ctx, models, fields, 0)
+}
+
+func (tx *Tx) BatchInsertReturning(models []Model, fields, returnFields uint64) (int64, error) {
+	return tx.BatchInsertReturningContext(tx.Context(), models, fields, returnFields)
+}
+
+func (tx *Tx) BatchInsertReturningContext(ctx context.Context, models []Model, fields, returnFields uint64) (int64, error) {
+	n, _, err := tx.batchInsert(ctx, models, fields, returnFields)
+	return n, err
+}
+
+func (tx *Tx) batchInsert(ctx context.Context, models []Model, fields, returnFields uint64) (int64, []int64, error) {
+	for _, model := range models {
+		if err := beforeHook(ctx, tx, model, INSERT); err != nil {
+			return 0, nil, err
+		}
+		fields = tx.db.stamp(model, BATCHINSERT, fields)
+	}
+
+	n, ids, err := batchInsert(ctx, tx, models, fields, returnFields, func(key stmtKey, args []interface{}) (Stmt, []interface{}, error) {
+		return tx.stmt(ctx, models[0], key, args)
+	})
+	for i := 0; i < len(models) && err == nil; i++ {
+		_, err = afterHook(ctx, tx, models[i], INSERT, n, nil)
+	}
+
+	return n, ids, err
+}
+
+func (tx *Tx) Update(model Model, fields, whereFields uint64) (int64, error) {
+	return tx.UpdateContext(tx.Context(), model, fields, whereFields)
 }
 
 func (tx *Tx) ArgsUpdate(model Model, fields, whereFields uint64, args ...interface{}) (int64, error) {
-	stmt, err := tx.Table(model).PrepareUpdate(tx, fields, whereFields)
+	return tx.ArgsUpdateContext(tx.Context(), model, fields, whereFields, args...)
+}
+
+func (tx *Tx) UpdateContext(ctx context.Context, model Model, fields, whereFields uint64) (int64, error) {
+	if err := beforeHook(ctx, tx, model, UPDATE); err != nil {
+		return 0, err
+	}
+
+	fields = tx.db.stamp(model, UPDATE, fields)
+	version := tx.Table(model).version
+	return tx.update(ctx, model, fields, whereFields, versionArgs(model, version, fields, whereFields), true)
+}
+
+func (tx *Tx) ArgsUpdateContext(ctx context.Context, model Model, fields, whereFields uint64, args ...interface{}) (int64, error) {
+	if err := beforeHook(ctx, tx, model, UPDATE); err != nil {
+		return 0, err
+	}
+
+	return tx.update(ctx, model, fields, whereFields, args, false)
+}
+
+// update execute update, if model is versioned, the version field of model is
+// increased after success if incr is true
+func (tx *Tx) update(ctx context.Context, model Model, fields, whereFields uint64, args []interface{}, incr bool) (int64, error) {
+	t := tx.Table(model)
+	stmt, args, err := tx.stmt(ctx, model, versionKey(t, fields, whereFields), args)
+	n, err := CloseUpdateContext(ctx, stmt, err, args...)
+	n, err = versionResult(model, t.version, incr, n, err)
 
-	return CloseUpdate(stmt, err, args...)
+	return afterHook(ctx, tx, model, UPDATE, n, err)
 }
 
 func (tx *Tx) Delete(model Model, whereFields uint64) (int64, error) {
-	return tx.ArgsDelete(model, whereFields, FieldVals(model, whereFields)...)
+	return tx.DeleteContext(tx.Context(), model, whereFields)
 }
 
 func (tx *Tx) ArgsDelete(model Model, whereFields uint64, args ...interface{}) (int64, error) {
-	stmt, err := tx.Table(model).PrepareDelete(tx, whereFields)
+	return tx.ArgsDeleteContext(tx.Context(), model, whereFields, args...)
+}
+
+func (tx *Tx) DeleteContext(ctx context.Context, model Model, whereFields uint64) (int64, error) {
+	if err := beforeHook(ctx, tx, model, DELETE); err != nil {
+		return 0, err
+	}
+
+	return tx.delete(ctx, model, whereFields, whereVals(model, whereFields))
+}
+
+func (tx *Tx) ArgsDeleteContext(ctx context.Context, model Model, whereFields uint64, args ...interface{}) (int64, error) {
+	if err := beforeHook(ctx, tx, model, DELETE); err != nil {
+		return 0, err
+	}
+
+	return tx.delete(ctx, model, whereFields, args)
+}
+
+func (tx *Tx) delete(ctx context.Context, model Model, whereFields uint64, args []interface{}) (int64, error) {
+	stmt, args, err := tx.stmt(ctx, model, stmtKey{sqlType: DELETE, whereFields: whereFields}, args)
+	n, err := CloseUpdateContext(ctx, stmt, err, args...)
 
-	return CloseUpdate(stmt, err, args...)
+	return afterHook(ctx, tx, model, DELETE, n, err)
 }
 
 // One select one row from database
 func (tx *Tx) One(model Model, fields, whereFields uint64) error {
-	return tx.ArgsOne(model, fields, whereFields, FieldVals(model, whereFields))
+	return tx.OneContext(tx.Context(), model, fields, whereFields)
 }
 
 func (tx *Tx) ArgsOne(model Model, fields, whereFields uint64, args []interface{}, ptrs ...interface{}) error {
-	stmt, err := tx.Table(model).PrepareOne(tx, fields, whereFields)
-	scanner := Query(stmt, err, args...)
-	defer scanner.Close()
+	return tx.ArgsOneContext(tx.Context(), model, fields, whereFields, args, ptrs...)
+}
 
-	if len(ptrs) == 0 {
-		ptrs = FieldPtrs(model, fields)
-	}
-	return scanner.One(ptrs...)
+func (tx *Tx) OneContext(ctx context.Context, model Model, fields, whereFields uint64) error {
+	return tx.ArgsOneContext(ctx, model, fields, whereFields, whereVals(model, whereFields))
 }
 
-func (tx *Tx) Limit(store Store, model Model, fields, whereFields uint64, start, count int64) error {
-	args := FieldVals(model, whereFields, start, count)
+func (tx *Tx) ArgsOneContext(ctx context.Context, model Model, fields, whereFields uint64, args []interface{}, ptrs ...interface{}) error {
+	return tx.ArgsOrderOneContext(ctx, model, fields, whereFields, Order{}, args, ptrs...)
+}
 
-	return tx.ArgsLimit(store, model, fields, whereFields, args...)
+func (tx *Tx) Limit(store Store, model Model, fields, whereFields uint64, start, count int64) error {
+	return tx.LimitContext(tx.Context(), store, model, fields, whereFields, start, count)
 }
 
 // The last two arguments must be "start" and "count" of limition with type "int"
 func (tx *Tx) ArgsLimit(store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
-	stmt, err := tx.Table(model).PrepareLimit(tx, fields, whereFields)
-	scanner := Query(stmt, err, args...)
-	defer scanner.Close()
+	return tx.ArgsLimitContext(tx.Context(), store, model, fields, whereFields, args...)
+}
 
-	return scanner.Limit(store, args[len(args)-1].(int))
+func (tx *Tx) LimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, start, count int64) error {
+	args := whereVals(model, whereFields, start, count)
+
+	return tx.ArgsLimitContext(ctx, store, model, fields, whereFields, args...)
+}
+
+func (tx *Tx) ArgsLimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
+	return tx.ArgsOrderLimitContext(ctx, store, model, fields, whereFields, Order{}, args...)
 }
 
 func (tx *Tx) All(store Store, model Model, fields, whereFields uint64) error {
-	return tx.ArgsAll(store, model, fields, whereFields, FieldVals(model, whereFields)...)
+	return tx.AllContext(tx.Context(), store, model, fields, whereFields)
 }
 
-// ArgsAll select all rows, the last two argument must be "start" and "count"
 func (tx *Tx) ArgsAll(store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
-	stmt, err := tx.Table(model).PrepareAll(tx, fields, whereFields)
-	scanner := Query(stmt, err, args...)
+	return tx.ArgsAllContext(tx.Context(), store, model, fields, whereFields, args...)
+}
+
+func (tx *Tx) AllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64) error {
+	return tx.ArgsAllContext(ctx, store, model, fields, whereFields, whereVals(model, whereFields)...)
+}
+
+func (tx *Tx) ArgsAllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, args ...interface{}) error {
+	return tx.ArgsOrderAllContext(ctx, store, model, fields, whereFields, Order{}, args...)
+}
+
+// OrderOne is similar to One, but rows are sorted by order
+func (tx *Tx) OrderOne(model Model, fields, whereFields uint64, order Order) error {
+	return tx.OrderOneContext(tx.Context(), model, fields, whereFields, order)
+}
+
+func (tx *Tx) ArgsOrderOne(model Model, fields, whereFields uint64, order Order, args []interface{}, ptrs ...interface{}) error {
+	return tx.ArgsOrderOneContext(tx.Context(), model, fields, whereFields, order, args, ptrs...)
+}
+
+func (tx *Tx) OrderOneContext(ctx context.Context, model Model, fields, whereFields uint64, order Order) error {
+	return tx.ArgsOrderOneContext(ctx, model, fields, whereFields, order, whereVals(model, whereFields))
+}
+
+func (tx *Tx) ArgsOrderOneContext(ctx context.Context, model Model, fields, whereFields uint64, order Order, args []interface{}, ptrs ...interface{}) error {
+	stmt, args, err := tx.stmt(ctx, model, stmtKey{sqlType: ONE, fields: fields, whereFields: whereFields, order: order}, args)
+	scanner := QueryContext(ctx, stmt, err, args...)
+	defer scanner.Close()
+
+	if len(ptrs) != 0 {
+		return scanner.One(ptrs...)
+	}
+	if err = scanner.One(FieldPtrs(model, fields)...); err != nil {
+		return err
+	}
+	return afterScan(model)
+}
+
+// OrderLimit is similar to Limit, but rows are sorted by order
+func (tx *Tx) OrderLimit(store Store, model Model, fields, whereFields uint64, order Order, start, count int64) error {
+	return tx.OrderLimitContext(tx.Context(), store, model, fields, whereFields, order, start, count)
+}
+
+func (tx *Tx) ArgsOrderLimit(store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error {
+	return tx.ArgsOrderLimitContext(tx.Context(), store, model, fields, whereFields, order, args...)
+}
+
+func (tx *Tx) OrderLimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, start, count int64) error {
+	args := whereVals(model, whereFields, start, count)
+
+	return tx.ArgsOrderLimitContext(ctx, store, model, fields, whereFields, order, args...)
+}
+
+func (tx *Tx) ArgsOrderLimitContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error {
+	count, err := limitArgs(tx.Driver(), args)
+	if err != nil {
+		return err
+	}
+
+	stmt, args, err := tx.stmt(ctx, model, stmtKey{sqlType: LIMIT, fields: fields, whereFields: whereFields, order: order}, args)
+	scanner := QueryContext(ctx, stmt, err, args...)
+	defer scanner.Close()
+
+	return scanner.Limit(store, count)
+}
+
+// OrderAll is similar to All, but rows are sorted by order
+func (tx *Tx) OrderAll(store Store, model Model, fields, whereFields uint64, order Order) error {
+	return tx.OrderAllContext(tx.Context(), store, model, fields, whereFields, order)
+}
+
+func (tx *Tx) ArgsOrderAll(store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error {
+	return tx.ArgsOrderAllContext(tx.Context(), store, model, fields, whereFields, order, args...)
+}
+
+func (tx *Tx) OrderAllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order) error {
+	return tx.ArgsOrderAllContext(ctx, store, model, fields, whereFields, order, whereVals(model, whereFields)...)
+}
+
+func (tx *Tx) ArgsOrderAllContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, args ...interface{}) error {
+	stmt, args, err := tx.stmt(ctx, model, stmtKey{sqlType: ALL, fields: fields, whereFields: whereFields, order: order}, args)
+	scanner := QueryContext(ctx, stmt, err, args...)
 	defer scanner.Close()
 
 	return scanner.All(store, tx.db.InitialModels)
 }
 
+func (tx *Tx) Iter(model Model, fields, whereFields uint64, order Order) Scanner {
+	return tx.IterContext(tx.Context(), model, fields, whereFields, order)
+}
+
+func (tx *Tx) ArgsIter(model Model, fields, whereFields uint64, order Order, args ...interface{}) Scanner {
+	return tx.ArgsIterContext(tx.Context(), model, fields, whereFields, order, args...)
+}
+
+func (tx *Tx) IterContext(ctx context.Context, model Model, fields, whereFields uint64, order Order) Scanner {
+	return tx.ArgsIterContext(ctx, model, fields, whereFields, order, whereVals(model, whereFields)...)
+}
+
+func (tx *Tx) ArgsIterContext(ctx context.Context, model Model, fields, whereFields uint64, order Order, args ...interface{}) Scanner {
+	stmt, args, err := tx.stmt(ctx, model, stmtKey{sqlType: ALL, fields: fields, whereFields: whereFields, order: order}, args)
+
+	return QueryContext(ctx, stmt, err, args...)
+}
+
+func (tx *Tx) Page(store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int) (string, error) {
+	return tx.PageContext(tx.Context(), store, model, fields, whereFields, order, cursor, count)
+}
+
+func (tx *Tx) ArgsPage(store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int, args ...interface{}) (string, error) {
+	return tx.ArgsPageContext(tx.Context(), store, model, fields, whereFields, order, cursor, count, args...)
+}
+
+func (tx *Tx) PageContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int) (string, error) {
+	return tx.ArgsPageContext(ctx, store, model, fields, whereFields, order, cursor, count, whereVals(model, whereFields)...)
+}
+
+func (tx *Tx) ArgsPageContext(ctx context.Context, store Store, model Model, fields, whereFields uint64, order Order, cursor string, count int, args ...interface{}) (string, error) {
+	key, args, err := pageArgs(model, fields, whereFields, order, cursor, count, args)
+	if err != nil {
+		return "", err
+	}
+
+	stmt, args, err := tx.stmt(ctx, model, key, args)
+	scanner := QueryContext(ctx, stmt, err, args...)
+	defer scanner.Close()
+
+	return scanPage(scanner, store, fields, order, count)
+}
+
 // Count return count of rows for model, arguments was extracted from Model
 func (tx *Tx) Count(model Model, whereFields uint64) (count int64, err error) {
-	return tx.ArgsCount(model, whereFields, FieldVals(model, whereFields)...)
+	return tx.CountContext(tx.Context(), model, whereFields)
 }
 
 // ArgsCount return count of rows for model use custome arguments
 func (tx *Tx) ArgsCount(model Model, whereFields uint64,
 	args ...interface{}) (count int64, err error) {
-	stmt, err := tx.Table(model).PrepareCount(tx, whereFields)
-	scanner := Query(stmt, err, args...)
+	return tx.ArgsCountContext(tx.Context(), model, whereFields, args...)
+}
+
+func (tx *Tx) CountContext(ctx context.Context, model Model, whereFields uint64) (count int64, err error) {
+	return tx.ArgsCountContext(ctx, model, whereFields, whereVals(model, whereFields)...)
+}
+
+func (tx *Tx) ArgsCountContext(ctx context.Context, model Model, whereFields uint64,
+	args ...interface{}) (count int64, err error) {
+	stmt, args, err := tx.stmt(ctx, model, stmtKey{sqlType: COUNT, whereFields: whereFields}, args)
+	scanner := QueryContext(ctx, stmt, err, args...)
 	defer scanner.Close()
 	err = scanner.One(&count)
 
@@ -122,29 +552,38 @@ func (tx *Tx) ArgsCount(model Model, whereFields uint64,
 }
 
 func (tx *Tx) IncrBy(model Model, field, whereFields uint64, counts ...int) (int64, error) {
-	cntLen := len(counts)
-	args := make([]interface{}, NumFields(whereFields)+cntLen)
-	for i, count := range counts {
-		args[i] = count
-	}
-	model.Vals(whereFields, args[cntLen:])
-
-	return tx.ArgsIncrBy(model, field, whereFields, args...)
+	return tx.IncrByContext(tx.Context(), model, field, whereFields, counts...)
 }
 
 func (tx *Tx) ArgsIncrBy(model Model, fields, whereFields uint64, args ...interface{}) (int64, error) {
-	stmt, err := tx.Table(model).PrepareIncrBy(tx, fields, whereFields)
+	return tx.ArgsIncrByContext(tx.Context(), model, fields, whereFields, args...)
+}
+
+func (tx *Tx) IncrByContext(ctx context.Context, model Model, field, whereFields uint64, counts ...int) (int64, error) {
+	return tx.ArgsIncrByContext(ctx, model, field, whereFields, incrByArgs(model, whereFields, counts)...)
+}
+
+func (tx *Tx) ArgsIncrByContext(ctx context.Context, model Model, fields, whereFields uint64, args ...interface{}) (int64, error) {
+	stmt, args, err := tx.stmt(ctx, model, stmtKey{sqlType: INCRBY, fields: fields, whereFields: whereFields}, args)
 
-	return CloseUpdate(stmt, err, args...)
+	return CloseUpdateContext(ctx, stmt, err, args...)
 }
 
 func (tx *Tx) Exists(model Model, fields, whereFields uint64) (exists bool, err error) {
-	return tx.ArgsExists(model, fields, whereFields, FieldVals(model, whereFields)...)
+	return tx.ExistsContext(tx.Context(), model, fields, whereFields)
 }
 
 func (tx *Tx) ArgsExists(model Model, fields, whereFields uint64, args ...interface{}) (exists bool, err error) {
-	stmt, err := tx.Table(model).PrepareExists(tx, fields, whereFields)
-	scanner := Query(stmt, err, args...)
+	return tx.ArgsExistsContext(tx.Context(), model, fields, whereFields, args...)
+}
+
+func (tx *Tx) ExistsContext(ctx context.Context, model Model, fields, whereFields uint64) (exists bool, err error) {
+	return tx.ArgsExistsContext(ctx, model, fields, whereFields, whereVals(model, whereFields)...)
+}
+
+func (tx *Tx) ArgsExistsContext(ctx context.Context, model Model, fields, whereFields uint64, args ...interface{}) (exists bool, err error) {
+	stmt, args, err := tx.stmt(ctx, model, stmtKey{sqlType: EXISTS, fields: fields, whereFields: whereFields}, args)
+	scanner := QueryContext(ctx, stmt, err, args...)
 	defer scanner.Close()
 	err = scanner.One(&exists)
 
@@ -152,16 +591,12 @@ func (tx *Tx) ArgsExists(model Model, fields, whereFields uint64, args ...interf
 }
 
 func (tx *Tx) QueryById(sqlid uint64, args ...interface{}) Scanner {
-	stmt, err := tx.PrepareById(sqlid)
-
-	return Query(stmt, err, args...)
+	return tx.QueryByIdContext(tx.Context(), sqlid, args...)
 }
 
 // ExecById execute a update operation, return rows affected
 func (tx *Tx) ExecById(sqlid uint64, resType ResultType, args ...interface{}) (int64, error) {
-	stmt, err := tx.PrepareById(sqlid)
-
-	return CloseExec(stmt, err, resType, args...)
+	return tx.ExecByIdContext(tx.Context(), sqlid, resType, args...)
 }
 
 // UpdateById execute a update operation, return resolved result
@@ -169,31 +604,65 @@ func (tx *Tx) UpdateById(sqlid uint64, args ...interface{}) (int64, error) {
 	return tx.ExecById(sqlid, RES_ROWS, args...)
 }
 
-func (tx *Tx) prepare(sql string) (Stmt, error) {
+func (tx *Tx) QueryByIdContext(ctx context.Context, sqlid uint64, args ...interface{}) Scanner {
+	stmt, err := tx.PrepareByIdContext(ctx, sqlid)
+
+	return QueryContext(ctx, stmt, err, args...)
+}
+
+func (tx *Tx) ExecByIdContext(ctx context.Context, sqlid uint64, resType ResultType, args ...interface{}) (int64, error) {
+	stmt, err := tx.PrepareByIdContext(ctx, sqlid)
+
+	return CloseExecContext(ctx, stmt, err, resType, args...)
+}
+
+func (tx *Tx) UpdateByIdContext(ctx context.Context, sqlid uint64, args ...interface{}) (int64, error) {
+	return tx.ExecByIdContext(ctx, sqlid, RES_ROWS, args...)
+}
+
+func (tx *Tx) prepare(ctx context.Context, sql string) (Stmt, error) {
 	sql = tx.db.driver.Prepare(sql)
 	sqlPrinter(sql)
-	stmt, err := tx.Tx.Prepare(sql)
-	return WrapStmt(true, stmt, err)
+	stmt, err := tx.Tx.PrepareContext(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	return withHook(tx, stmt, stmtInfo{sql: sql, sqlType: RAW}), nil
 }
 
 func (tx *Tx) Query(sql string, args ...interface{}) Scanner {
-	stmt, err := tx.prepare(sql)
-	return Query(stmt, err)
+	return tx.QueryContext(tx.Context(), sql, args...)
 }
 
 func (tx *Tx) Exec(sql string, resType ResultType, args ...interface{}) (int64, error) {
-	stmt, err := tx.prepare(sql)
-	return CloseExec(stmt, err, resType, args...)
+	return tx.ExecContext(tx.Context(), sql, resType, args...)
 }
 
 func (tx *Tx) ExecUpdate(sql string, args ...interface{}) (int64, error) {
 	return tx.Exec(sql, RES_ROWS, args...)
 }
 
+func (tx *Tx) QueryContext(ctx context.Context, sql string, args ...interface{}) Scanner {
+	stmt, err := tx.prepare(ctx, sql)
+	return QueryContext(ctx, stmt, err, args...)
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, sql string, resType ResultType, args ...interface{}) (int64, error) {
+	stmt, err := tx.prepare(ctx, sql)
+	return CloseExecContext(ctx, stmt, err, resType, args...)
+}
+
+func (tx *Tx) ExecUpdateContext(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return tx.ExecContext(ctx, sql, RES_ROWS, args...)
+}
+
 // Done check if error is nil then commit transaction, otherwise rollback.
 // Done should be called only once, otherwise it will panic.
 // Done should be called in deferred function to avoid uncommitted/unrollbacked
 // transaction caused by panic.
+// If the context of transaction is done, the transaction has been rollbacked by
+// database/sql, the error of context is returned.
 //
 // Example:
 //  func operation() (err error) {
@@ -213,24 +682,127 @@ func (tx *Tx) Close() error {
 	if tx.isSuccess {
 		err := tx.Commit()
 		if err == sql.ErrTxDone {
-			panic("commit transaction twice")
+			err = tx.doneErr("commit transaction twice")
 		}
 		return err
 	}
 
 	err := tx.Rollback()
 	if err == sql.ErrTxDone {
-		panic("rollback a committed/rollbacked transaction")
+		err = tx.doneErr("rollback a committed/rollbacked transaction")
+	} else if err == nil {
+		err = tx.Context().Err()
 	}
 	return err
 }
 
+// doneErr return the error of context if the transaction is rollbacked by
+// database/sql for context done, otherwise it's closed twice, panic with msg
+func (tx *Tx) doneErr(msg string) error {
+	if err := tx.Context().Err(); err != nil {
+		return err
+	}
+
+	panic(msg)
+}
+
 func (tx *Tx) Success(success bool) {
 	tx.isSuccess = tx.isSuccess && success
 }
 
+// ErrInvalidSavepoint means the savepoint name is not a valid identifier
+var ErrInvalidSavepoint = errors.New("savepoint name must be an identifier")
+
+// Savepoint create a savepoint in transaction, name must be an identifier
+// consists of letters, digits and underscore, and not start with digit
+func (tx *Tx) Savepoint(name string) error {
+	return tx.savepoint("SAVEPOINT ", name)
+}
+
+// RollbackTo rollback the transaction to the savepoint, the savepoint is keeped
+func (tx *Tx) RollbackTo(name string) error {
+	return tx.savepoint("ROLLBACK TO SAVEPOINT ", name)
+}
+
+// Release remove the savepoint, changes after it are keeped in transaction
+func (tx *Tx) Release(name string) error {
+	return tx.savepoint("RELEASE SAVEPOINT ", name)
+}
+
+// savepoint execute the savepoint statement directly without preparing, the
+// hook, metrics and tracer of DB are called
+func (tx *Tx) savepoint(sql, name string) error {
+	if !isIdentifier(name) {
+		return ErrInvalidSavepoint
+	}
+
+	sql += name
+	sqlPrinter(sql)
+	stmt := withHook(tx, directStmt{tx: tx.Tx, sql: sql}, stmtInfo{sql: sql, sqlType: RAW})
+	_, err := stmt.ExecContext(tx.Context())
+	return err
+}
+
+// isIdentifier check whether the name consists of letters, digits and
+// underscore, and not start with digit
+func isIdentifier(name string) bool {
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+
+	return name != ""
+}
+
+// TxDo run function in a nested transaction implemented by savepoint, if it
+// return an error, call Success(false) or panic, only changes in the function
+// are rollbacked, the outer transaction is not affected, the panic is
+// propagated after rollbacked.
+func (tx *Tx) TxDo(do func(*Tx) error) (err error) {
+	tx.nested++
+	name := "gomodel_sp" + strconv.Itoa(tx.nested)
+	if err = tx.Savepoint(name); err != nil {
+		tx.nested--
+		return err
+	}
+
+	success := tx.isSuccess
+	tx.isSuccess = true
+	defer func() {
+		p := recover()
+		if p != nil || err != nil || !tx.isSuccess {
+			if e := tx.RollbackTo(name); e != nil {
+				err = e
+			}
+		}
+		if e := tx.Release(name); e != nil {
+			if err == nil {
+				err = e
+			} else {
+				err = fmt.Errorf("%w, release savepoint: %v", err, e)
+			}
+		}
+		tx.isSuccess = success
+		tx.nested--
+		if p != nil {
+			panic(p)
+		}
+	}()
+
+	return do(tx)
+}
+
 func (tx *Tx) PrepareById(sqlid uint64) (Stmt, error) {
-	stmt, err := tx.db.cache.PrepareById(tx, sqlid)
+	return tx.PrepareByIdContext(tx.Context(), sqlid)
+}
 
-	return WrapStmt(STMT_CLOSEABLE, stmt, err)
+// PrepareByIdContext get the cached statement of DB by id, and bind it to
+// transaction
+func (tx *Tx) PrepareByIdContext(ctx context.Context, sqlid uint64) (Stmt, error) {
+	stmt, err := tx.db.stmtById(ctx, sqlid)
+	return tx.bind(ctx, stmt, err)
 }
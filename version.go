@@ -0,0 +1,79 @@
+package gomodel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrVersionConflict is returned by Update of versioned models if no rows is
+// affected, the row is modified by others or doesn't exist
+var ErrVersionConflict = errors.New("version conflict")
+
+// versionWhere append the condition of version field to where clause
+func (t *Table) versionWhere(where string, version uint64) string {
+	cond := t.Col(version) + "=?"
+	if where == "" {
+		return "WHERE " + cond
+	}
+	return where + " AND " + cond
+}
+
+// sqlVersionUpdate is similar to sqlUpdate, the version field is increased
+func (t *Table) sqlVersionUpdate(cols Cols, version uint64, where string) string {
+	col := t.Col(version)
+	set := col + "=" + col + "+1"
+	if cols.Length() != 0 {
+		set = cols.Paramed() + "," + set
+	}
+
+	return fmt.Sprintf("UPDATE %s SET %s %s",
+		t.Name,
+		set,
+		where)
+}
+
+// versionKey create the statement key of update, the version field is removed
+// from fields and where fields
+func versionKey(t *Table, fields, whereFields uint64) stmtKey {
+	return stmtKey{
+		sqlType:     UPDATE,
+		fields:      fields &^ t.version,
+		whereFields: whereFields &^ t.version,
+		version:     t.version,
+	}
+}
+
+// versionArgs create arguments of update, current value of version field is
+// appended if model is versioned
+func versionArgs(model Model, version, fields, whereFields uint64) []interface{} {
+	args := updateArgs(model, fields&^version, whereFields&^version)
+	if version == 0 {
+		return args
+	}
+
+	return append(args, FieldVals(model, version)...)
+}
+
+// versionResult check the result of versioned update, the version field of
+// model is increased if incr is true and the update is succeed
+func versionResult(model Model, version uint64, incr bool, n int64, err error) (int64, error) {
+	if err != nil || version == 0 {
+		return n, err
+	}
+	if n == 0 {
+		return n, ErrVersionConflict
+	}
+
+	if incr {
+		ptrs := FieldPtrs(model, version)
+		switch v := reflect.ValueOf(ptrs[0]).Elem(); v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(v.Int() + 1)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(v.Uint() + 1)
+		}
+	}
+
+	return n, nil
+}